@@ -0,0 +1,14 @@
+(setq x 1)
+(prog (x) (
+  (set! x (plus x 1))
+  (print x)
+))
+(print x)
+(prog () (
+  (set! x (plus x 1))
+))
+(print x)
+(func counter () (let ((n 0)) (lambda () (let () (set! n (plus n 1)) n))))
+(setq next (counter))
+(next)
+(print (next))
@@ -0,0 +1,22 @@
+(func sign (x) (cond
+  ((less x 0) (minus 0 1))
+  ((greater x 0) 1)
+  (else 0)
+))
+
+// 1
+(sign 42)
+// 0
+(sign 0)
+
+(func size (l) (case (head l)
+  (0 0)
+  ((1 2 3) (print (head l)) 1)
+  (else 2)
+))
+
+// 2
+// 1
+(size '(2 5))
+// 2
+(size '(7))
@@ -0,0 +1,14 @@
+(func adder (n) (lambda (x) (plus x n)))
+
+(setq addFive (adder 5))
+
+// 15
+(addFive 10)
+
+(func compose (f g) (lambda (x) (f (g x))))
+(func double (x) (times x 2))
+
+(setq doubleAfterFive (compose double addFive))
+
+// 22
+(doubleAfterFive 6)
@@ -0,0 +1,8 @@
+(func isqrt (n)
+    :pre (greatereq n 0)
+    :post (lesseq (times result result) n)
+    (floor (sqrt n)))
+(print (isqrt 17))
+(assert (equal (isqrt 16) 4) "isqrt of a square")
+(print (try (isqrt (minus 0 1))
+    (catch e (error-message e))))
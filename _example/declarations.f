@@ -0,0 +1,11 @@
+(const limit 3)
+(define total 0)
+(define i 0)
+(while (less i limit)
+    (setq i (plus i 1))
+    (setq total (plus total i)))
+(print total)
+(print (try (setq limit 10) (catch e (error-message e))))
+(func reset (n) (define n 0))
+(print (try (reset 1) (catch e (error-kind e))))
+(print (let ((limit 5)) (plus limit 1)))
@@ -0,0 +1,16 @@
+(func sumPositive (l)
+    (match l
+        ('() 0)
+        ((cons h t) when (less h 0) (sumPositive t))
+        ((cons h t) (plus h (sumPositive t)))))
+(print (sumPositive (cons (minus 0 5) '(1 2 3))))
+(func pairs (l)
+    (match l
+        ((cons a (cons b rest)) (cons (plus a b) (pairs rest)))
+        ((list a) (cons a null))
+        (_ null)))
+(print (pairs '(1 2 3 4 5)))
+(func swap ((list a b)) (cons b (cons a null)))
+(print (swap '(1 2)))
+(print (map (lambda ((list k v)) (times k v)) '((1 2) (3 4))))
+(let (((cons h t) '(10 20 30))) (print h) (print t))
@@ -0,0 +1,16 @@
+(func safeDivide (a b)
+    (try (divide a b)
+        (catch e (print (error-message e)) 0)))
+(print (safeDivide 1 2))
+(print (safeDivide 1 0))
+(func find (key alist)
+    (let ((pair (assoc key alist)))
+        (cond (isnull pair) (throw (error 'not-found "no such key" key)) (head (tail pair)))))
+(setq ages (cons '(ann 31) (cons '(bob 27) null)))
+(print (find 'ann ages))
+(print (try (find 'eve ages)
+    (catch e (cond (equal (error-kind e) 'not-found) (error-data e) (throw e)))))
+(print (try (plus 1 (head '()))
+    (catch e (error-kind e))
+    (finally (print "finally"))))
+(print (error? (try (throw 42) (catch e e))))
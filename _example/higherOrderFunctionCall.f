@@ -1,2 +1,13 @@
-//skip
-((lambda (x) (times 2 x)) (5))
\ No newline at end of file
+// 10
+((lambda (x) (times 2 x)) 5)
+
+(func adder (n) (lambda (x) (plus x n)))
+
+// 7
+((adder 2) 5)
+
+// 6
+(apply plus '(2 4))
+
+// 12
+(funcall times 3 4)
@@ -0,0 +1,16 @@
+(setq x 10)
+
+// 11: y sees the outer x
+(print (let ((x 1) (y (plus x 1))) (times x y)))
+
+// 2: y sees the x bound just before
+(print (let* ((x 1) (y (plus x 1))) (times x y)))
+
+// true: local functions may call each other
+(print (letrec (
+  (isEven (lambda (n) (cond (equal n 0) true (isOdd (minus n 1)))))
+  (isOdd (lambda (n) (cond (equal n 0) false (isEven (minus n 1)))))
+) (isEven 10)))
+
+// 10: let bindings are gone after the body
+(print x)
@@ -0,0 +1,34 @@
+// 1
+// 2
+// 3
+(for-each (x '(1 2 3)) (print x))
+
+// 0
+// 1
+// 2
+(dotimes (i 3) (print i))
+
+// 10
+// 8
+// 6
+(for (i 10 4 (minus 0 2)) (print i))
+
+(func indexOf (l v) (prog (l v) (
+  (setq idx 0)
+  (for-each (x l)
+    (cond (equal x v) (return idx))
+    (setq idx (plus idx 1))
+  )
+  (return (minus 0 1))
+)))
+
+// 2
+(print (indexOf '(5 6 7 8) 7))
+
+// 1
+// 3
+(for (i 1 10)
+  (cond (equal i 2) (continue))
+  (cond (greater i 3) (break))
+  (print i)
+)
@@ -0,0 +1,13 @@
+(setq colors (hashmap 'red "#f00" 'green "#0f0"))
+(print colors)
+(print (mapget colors 'red))
+(print (mapget colors 'blue "unknown"))
+(setq more (mapput colors 'blue "#00f"))
+(print (mapkeys more))
+(print (maphas colors 'blue))
+(print (mapdel more 'red))
+(print (mapmerge colors (hashmap 'red "#e00")))
+(func countWords (words)
+    (foldl (lambda (m w) (mapput m w (plus (mapget m w 0) 1))) (hashmap) words))
+(print (countWords '(a b a c b a)))
+(print '((a 1) (b 2)))
@@ -0,0 +1,13 @@
+(deftype expr (num n) (add a b) (mul a b) (neg a))
+(func calc (e)
+    (match e
+        ((num n) n)
+        ((add a b) (plus (calc a) (calc b)))
+        ((mul (num 0) _) 0)
+        ((mul a b) (times (calc a) (calc b)))
+        ((neg a) (minus 0 (calc a)))))
+(setq e (add (num 1) (mul (num 2) (neg (num 3)))))
+(print e)
+(print (calc e))
+(print (match '(1 2) ((list a b) (plus a b))))
+(print (match 'green ('red 1) ('green 2) (_ 0)))
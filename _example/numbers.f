@@ -0,0 +1,12 @@
+(func factorial (n) (cond (equal n 0) 1 (times n (factorial (minus n 1)))))
+(print (factorial 25))
+(print (divide 1 3))
+(print (plus (divide 1 3) (divide 2 3)))
+(print (plus (divide 1 2) 0.25))
+(print (quotient 17 5))
+(print (modulo (minus 0 7) 2))
+(print (remainder (minus 0 7) 2))
+(print (gcd 12 18))
+(print (float (divide 1 4)))
+(print (exact 0.75))
+(print (plus 1/2 1/3))
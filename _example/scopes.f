@@ -12,8 +12,10 @@
 (print (test 10))
 
 (setq x 0)
-(while (not (equal x 5)) (prog (x) (
+// 1
+// 2
+(while (not (equal x 5))
   (setq x (plus x 1))
   (print x)
   (cond (equal x 2) (break))
-)))
\ No newline at end of file
+)
@@ -0,0 +1,17 @@
+(defstruct point x y)
+(defstruct rect origin width height)
+(func area (r) (times (rect-width r) (rect-height r)))
+(func contains (r p)
+    (and (greatereq (point-x p) (point-x (rect-origin r)))
+         (greatereq (point-y p) (point-y (rect-origin r)))
+         (less (point-x p) (plus (point-x (rect-origin r)) (rect-width r)))
+         (less (point-y p) (plus (point-y (rect-origin r)) (rect-height r)))))
+(setq r (make-rect (make-point 0 0) 4 3))
+(print r)
+(print (area r))
+(print (contains r (make-point 1 2)))
+(print (contains r (make-point 5 2)))
+(setq moved (rect-with r 'origin (make-point 10 10)))
+(print moved)
+(print (equal r moved))
+(print (ispoint (rect-origin moved)))
@@ -0,0 +1,28 @@
+// sums the numbers up to n, skipping 3
+(func sumNoThree (n) (prog (n) (
+  (setq i 0)
+  (setq sum 0)
+  (while (less i n)
+    (setq i (plus i 1))
+    (cond (equal i 3) (continue))
+    (setq sum (plus sum i))
+  )
+  (return sum)
+)))
+
+// 52
+(print (sumNoThree 10))
+
+// returns the first element greater than n
+(func firstGreater (l n) (prog (l n) (
+  (while (not (empty l))
+    (prog (l n) (
+      (cond (greater (head l) n) (return (head l)))
+    ))
+    (setq l (tail l))
+  )
+  (return null)
+)))
+
+// 7
+(print (firstGreater '(1 5 7 9) 5))
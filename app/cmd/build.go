@@ -9,15 +9,26 @@ import (
 	"io"
 	"errors"
 	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/prelude"
 	"encoding/json"
+	"context"
+	"time"
 )
 
 // Run command builds the program at the specified path.
 type Run struct {
-	FileLocation string `short:"f" long:"file" env:"FILE"`
-	FailOnError  bool   `short:"e" long:"error" env:"ERROR"`
-	PrintAST     bool   `short:"a" long:"ast" env:"AST"`
-	PrintJSONAST bool   `short:"j" long:"json-ast" env:"JSON_AST"`
+	FileLocation string        `short:"f" long:"file" env:"FILE"`
+	FailOnError  bool          `short:"e" long:"error" env:"ERROR"`
+	PrintAST     bool          `short:"a" long:"ast" env:"AST"`
+	PrintJSONAST bool          `short:"j" long:"json-ast" env:"JSON_AST"`
+	MaxDepth     int           `long:"max-depth" env:"MAX_DEPTH" description:"max depth of nested function calls, 0 for no limit"`
+	MaxSteps     int           `long:"max-steps" env:"MAX_STEPS" description:"max number of evaluated expressions, 0 for no limit"`
+	MaxValues    int           `long:"max-values" env:"MAX_VALUES" description:"max number of allocated values, 0 for no limit"`
+	Timeout      time.Duration `long:"timeout" env:"TIMEOUT" description:"max time to run the program, 0 for no limit"`
+	Seed         *int64        `long:"seed" env:"SEED" description:"seed of the random number generator, random if not set"`
+	NoPrelude    bool          `long:"no-prelude" env:"NO_PRELUDE" description:"don't load the standard prelude"`
+	NoContracts  bool          `long:"no-contracts" env:"NO_CONTRACTS" description:"don't check assertions and contracts of functions"`
+	Strict       bool          `long:"strict" env:"STRICT" description:"let setq update only the variables declared with define or const"`
 }
 
 // Execute runs the command.
@@ -32,10 +43,29 @@ func (b Run) Execute(_ []string) error {
 		rd = f
 	}
 
+	ctx := context.Background()
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+
 	lex := lexer.NewLexer(rd)
 
 	p := parser.NewParser(lex)
 	scope := eval.NewScope("", nil, false)
+	if !b.NoPrelude {
+		var err error
+		if scope, err = prelude.NewScope(false); err != nil {
+			return fmt.Errorf("load prelude: %w", err)
+		}
+	}
+	scope.SetLimits(eval.Limits{MaxDepth: b.MaxDepth, MaxSteps: b.MaxSteps, MaxValues: b.MaxValues})
+	if b.Seed != nil {
+		scope.SetSeed(*b.Seed)
+	}
+	scope.SetContracts(!b.NoContracts)
+	scope.SetStrict(b.Strict)
 
 	for {
 		if b.FileLocation == "" {
@@ -64,9 +94,14 @@ func (b Run) Execute(_ []string) error {
 			log.Printf("[INFO] ast in json representation: \n%s", bts)
 		}
 
-		res, err := scope.Eval(expr)
+		res, err := scope.EvalContext(ctx, expr)
 		if err != nil {
-			log.Printf("[WARN] execute statement %s: %v", expr.String(), err)
+			var evalErr *eval.EvalError
+			if errors.As(err, &evalErr) {
+				log.Printf("[WARN] execute statement %s: %s", expr.String(), evalErr.Traceback())
+			} else {
+				log.Printf("[WARN] execute statement %s: %v", expr.String(), err)
+			}
 			if !b.FailOnError {
 				continue
 			}
@@ -0,0 +1,80 @@
+package eval_test
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/lexer"
+	"github.com/cappuccinotm/flangc/app/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func benchmark(b *testing.B, defs, call string) {
+	b.Helper()
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	scope := eval.NewScope("", nil, false)
+	p := parser.NewParser(lexer.NewLexer(strings.NewReader(defs + call)))
+	var exprs []eval.Expression
+	for {
+		expr, err := p.ParseNext()
+		if err != nil {
+			break
+		}
+		exprs = append(exprs, expr)
+	}
+	for _, expr := range exprs[:len(exprs)-1] {
+		_, err := scope.Eval(expr)
+		require.NoError(b, err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := scope.Eval(exprs[len(exprs)-1])
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkFib(b *testing.B) {
+	benchmark(b, `
+(func fib (n)
+    (cond (less n 2) n (plus (fib (minus n 1)) (fib (minus n 2)))))
+`, "(fib 15)")
+}
+
+func BenchmarkFactorial(b *testing.B) {
+	benchmark(b, `
+(func fact (n acc)
+    (cond (equal n 0) acc (fact (minus n 1) (times n acc))))
+`, "(fact 200 1)")
+}
+
+func BenchmarkAckermann(b *testing.B) {
+	benchmark(b, `
+(func ack (m n)
+    (cond
+        ((equal m 0) (plus n 1))
+        ((equal n 0) (ack (minus m 1) 1))
+        (else (ack (minus m 1) (ack m (minus n 1))))))
+`, "(ack 2 3)")
+}
+
+func BenchmarkClosures(b *testing.B) {
+	benchmark(b, `
+(func adder (n) (lambda (x) (plus x n)))
+(func sum (l f) (cond (empty l) 0 (plus (f (head l)) (sum (tail l) f))))
+`, "(sum (range 0 100) (adder 1))")
+}
+
+func BenchmarkMapPut(b *testing.B) {
+	benchmark(b, `
+(func fill (m n) (cond (equal n 0) m (fill (mapput m n n) (minus n 1))))
+`, "(fill (hashmap) 1000)")
+}
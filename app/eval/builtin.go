@@ -1,17 +1,66 @@
 package eval
 
+import "math"
+
 var builtinMethods map[string]func(*Scope, *Call) (Expression, error)
 
+// builtinConstants are the values of the names, which aren't defined by
+// the program.
+var builtinConstants = map[string]Expression{
+	"pi": &Number{Value: math.Pi},
+	"e":  &Number{Value: math.E},
+}
+
+// specialForms are the builtins, which take their arguments unevaluated or
+// change the flow of the evaluation, the program can't redefine them. The
+// rest of builtins are functions, which the program may shadow with its own
+// definitions.
+var specialForms = map[string]bool{
+	"quote": true, "setq": true, "set!": true, "define": true, "const": true,
+	"func": true, "lambda": true, "defstruct": true, "deftype": true,
+	"let": true, "let*": true, "letrec": true,
+	"cond": true, "case": true, "match": true, "and": true, "or": true,
+	"while": true, "for-each": true, "dotimes": true, "for": true,
+	"break": true, "continue": true, "return": true, "prog": true,
+	"try": true, "assert": true,
+}
+
 func init() {
 	builtinMethods = map[string]func(*Scope, *Call) (Expression, error){
 		"quote":    (*Scope).quote,
 		"equal":    (*Scope).equal,
 		"nonequal": (*Scope).nonequal,
 		// list
-		"head":  (*Scope).head,
-		"tail":  (*Scope).tail,
-		"cons":  (*Scope).cons,
-		"empty": (*Scope).empty,
+		"head":    (*Scope).head,
+		"tail":    (*Scope).tail,
+		"cons":    (*Scope).cons,
+		"empty":   (*Scope).empty,
+		"last":    (*Scope).last,
+		"length":  (*Scope).length,
+		"nth":     (*Scope).nth,
+		"take":    (*Scope).take,
+		"drop":    (*Scope).drop,
+		"append":  (*Scope).appendLists,
+		"reverse": (*Scope).reverse,
+		"range":   (*Scope).rangeList,
+		"zip":     (*Scope).zip,
+		"member":  (*Scope).member,
+		"assoc":   (*Scope).assoc,
+		"map":     (*Scope).mapList,
+		"filter":  (*Scope).filter,
+		"foldl":   (*Scope).foldl,
+		"foldr":   (*Scope).foldr,
+		"reduce":  (*Scope).reduce,
+		"sort":    (*Scope).sortList,
+		// map
+		"hashmap":   (*Scope).hashmap,
+		"mapget":    (*Scope).mapget,
+		"mapput":    (*Scope).mapput,
+		"mapdel":    (*Scope).mapdel,
+		"maphas":    (*Scope).maphas,
+		"mapkeys":   (*Scope).mapkeys,
+		"mapvalues": (*Scope).mapvalues,
+		"mapmerge":  (*Scope).mapmerge,
 		// arithmetic
 		"times":     (*Scope).times,
 		"plus":      (*Scope).plus,
@@ -21,27 +70,88 @@ func init() {
 		"lesseq":    (*Scope).lesseq,
 		"greater":   (*Scope).greater,
 		"greatereq": (*Scope).greatereq,
+		// integers and conversions
+		"quotient":    (*Scope).quotient,
+		"remainder":   (*Scope).remainder,
+		"modulo":      (*Scope).modulo,
+		"gcd":         (*Scope).gcd,
+		"float":       (*Scope).float,
+		"exact":       (*Scope).exact,
+		"numerator":   (*Scope).numerator,
+		"denominator": (*Scope).denominator,
+		// math
+		"sqrt":     (*Scope).sqrt,
+		"pow":      (*Scope).pow,
+		"exp":      float1(math.Exp, nil),
+		"log":      float1(math.Log, positive),
+		"sin":      float1(math.Sin, nil),
+		"cos":      float1(math.Cos, nil),
+		"tan":      float1(math.Tan, nil),
+		"asin":     float1(math.Asin, unit),
+		"acos":     float1(math.Acos, unit),
+		"atan":     (*Scope).atan,
+		"floor":    rounding(floorRat, math.Floor),
+		"ceil":     rounding(ceilRat, math.Ceil),
+		"round":    rounding(roundRat, math.RoundToEven),
+		"truncate": rounding(truncateRat, math.Trunc),
+		"abs":      (*Scope).abs,
+		"min":      (*Scope).min,
+		"max":      (*Scope).max,
+		"random":   (*Scope).random,
 		// logic
 		"and": (*Scope).and,
 		"or":  (*Scope).or,
 		"not": (*Scope).not,
 		"xor": (*Scope).xor,
 		// predecates
-		"isnull": is("null"),
-		"isbool": is("boolean"),
-		"islist": is("list"),
-		"isnum":  is("number"),
+		"isnull":   is("null"),
+		"isbool":   is("boolean"),
+		"islist":   is("list"),
+		"isnum":    is("number"),
+		"isfunc":   is("function"),
+		"ismap":    is("map"),
+		"isstring": is("string"),
+		"issymbol": is("symbol"),
 		// state-related
-		"setq": (*Scope).setq,
-		"func": (*Scope).setfn,
+		"setq":   (*Scope).setq,
+		"set!":   (*Scope).set,
+		"define": (*Scope).define,
+		"const":  (*Scope).constant,
+		"func":   (*Scope).setfn,
+		"lambda": (*Scope).lambda,
+		// records
+		"defstruct": (*Scope).defstruct,
+		"deftype":   (*Scope).deftype,
+		// bindings
+		"let":    (*Scope).let,
+		"let*":   (*Scope).letSeq,
+		"letrec": (*Scope).letrec,
 		// execution flow
-		"cond":   (*Scope).cond,
-		"while":  (*Scope).while,
-		"break":  (*Scope).brk,
-		"return": (*Scope).ret,
-		"print":  (*Scope).Print,
-		"prog":   (*Scope).prog,
-		"eval":   (*Scope).eval,
+		"cond":     (*Scope).cond,
+		"case":     (*Scope).selectCase,
+		"match":    (*Scope).match,
+		"while":    (*Scope).while,
+		"for-each": (*Scope).forEach,
+		"dotimes":  (*Scope).dotimes,
+		"for":      (*Scope).forRange,
+		"break":    (*Scope).brk,
+		"continue": (*Scope).cont,
+		"return":   (*Scope).ret,
+		"print":    (*Scope).Print,
+		"prog":     (*Scope).prog,
+		"eval":     (*Scope).eval,
+		"funcall":  (*Scope).funcall,
+		"apply":    (*Scope).apply,
+		// errors
+		"throw":         (*Scope).throw,
+		"try":           (*Scope).try,
+		"error":         (*Scope).makeError,
+		"error?":        is("error"),
+		"error-kind":    errorField(errorKind),
+		"error-message": errorField(errorMessage),
+		"error-data":    errorField(errorData),
+		// contracts
+		"assert": (*Scope).assert,
 	}
 }
 
@@ -50,17 +160,22 @@ func (s *Scope) quote(call *Call) (Expression, error) {
 }
 
 func is(typ string) func(*Scope, *Call) (Expression, error) {
-	return func(_ *Scope, call *Call) (Expression, error) {
+	return func(s *Scope, call *Call) (Expression, error) {
 		if len(call.Args) != 1 {
-			return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+			return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
+		}
+
+		expr, err := s.Eval(call.Args[0])
+		if err != nil {
+			return nil, err
 		}
 
 		if typ == "null" {
-			_, ok := call.Args[0].(Null)
+			_, ok := expr.(Null)
 			return &Boolean{Value: ok}, nil
 		}
 
-		return &Boolean{Value: call.Args[0].Type() == typ}, nil
+		return &Boolean{Value: expr.Type() == typ}, nil
 	}
 }
 
@@ -74,7 +189,7 @@ func (s *Scope) nonequal(call *Call) (Expression, error) {
 
 func (s *Scope) equal(call *Call) (Expression, error) {
 	if len(call.Args) != 2 {
-		return nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
 	}
 	a, err := s.Eval(call.Args[0])
 	if err != nil {
@@ -1,87 +1,180 @@
 package eval
 
+import (
+	"math"
+	"math/big"
+)
+
 func (s *Scope) greatereq(call *Call) (Expression, error) {
-	greaterv, err := s.greater(call)
+	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
+	return &Boolean{Value: compareNumbers(arg1, arg2) >= 0}, nil
+}
 
-	eqv, err := s.equal(call)
+func (s *Scope) greater(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-
-	return &Boolean{Value: greaterv.(*Boolean).Value || eqv.(*Boolean).Value}, nil
+	return &Boolean{Value: compareNumbers(arg1, arg2) > 0}, nil
 }
 
-func (s *Scope) greater(call *Call) (Expression, error) {
+func (s *Scope) lesseq(call *Call) (Expression, error) {
 	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	return &Boolean{Value: arg1.Value > arg2.Value}, nil
+	return &Boolean{Value: compareNumbers(arg1, arg2) <= 0}, nil
 }
 
-func (s *Scope) lesseq(call *Call) (Expression, error) {
-	lessv, err := s.less(call)
+func (s *Scope) less(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
+	return &Boolean{Value: compareNumbers(arg1, arg2) < 0}, nil
+}
 
-	eqv, err := s.equal(call)
+func (s *Scope) minus(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-
-	return &Boolean{Value: lessv.(*Boolean).Value || eqv.(*Boolean).Value}, nil
+	return checkNumber(opSub.apply(arg1, arg2))
 }
 
-func (s *Scope) less(call *Call) (Expression, error) {
+// div divides the numbers, the quotient of exact numbers stays exact:
+//
+//	(divide 1 3) // 1/3
+func (s *Scope) div(call *Call) (Expression, error) {
 	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	return &Boolean{Value: arg1.Value < arg2.Value}, nil
+	return divideNumbers(arg1, arg2)
 }
 
-func (s *Scope) minus(call *Call) (Expression, error) {
+func (s *Scope) plus(call *Call) (Expression, error) {
 	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	return &Number{Value: arg1.Value - arg2.Value}, nil
+	return checkNumber(opAdd.apply(arg1, arg2))
 }
 
-func (s *Scope) div(call *Call) (Expression, error) {
+func (s *Scope) times(call *Call) (Expression, error) {
 	arg1, arg2, err := s.castArithmeticArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	if arg2.Value == 0 {
+	return checkNumber(opMul.apply(arg1, arg2))
+}
+
+// quotient divides the integers, truncating the result towards zero.
+func (s *Scope) quotient(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castIntegerArguments(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	if arg2.Sign() == 0 {
 		return nil, ErrZeroDivision
 	}
-	return &Number{Value: arg1.Value / arg2.Value}, nil
+	return NewInteger(new(big.Int).Quo(arg1, arg2)), nil
 }
 
-func (s *Scope) plus(call *Call) (Expression, error) {
-	arg1, arg2, err := s.castArithmeticArguments(call.Args)
+// remainder returns the remainder of quotient, which has the sign of the
+// dividend, e.g. -1 for -7 and 2.
+func (s *Scope) remainder(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castIntegerArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	return &Number{Value: arg1.Value + arg2.Value}, nil
+	if arg2.Sign() == 0 {
+		return nil, ErrZeroDivision
+	}
+	return NewInteger(new(big.Int).Rem(arg1, arg2)), nil
 }
 
-func (s *Scope) times(call *Call) (Expression, error) {
-	arg1, arg2, err := s.castArithmeticArguments(call.Args)
+// modulo returns the remainder, which has the sign of the divisor, e.g. 1
+// for -7 and 2.
+func (s *Scope) modulo(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castIntegerArguments(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	if arg2.Sign() == 0 {
+		return nil, ErrZeroDivision
+	}
+	m := new(big.Int).Rem(arg1, arg2)
+	if m.Sign() != 0 && m.Sign() != arg2.Sign() {
+		m.Add(m, arg2)
+	}
+	return NewInteger(m), nil
+}
+
+// gcd returns the greatest common divisor of the integers, it is never
+// negative.
+func (s *Scope) gcd(call *Call) (Expression, error) {
+	arg1, arg2, err := s.castIntegerArguments(call.Args)
 	if err != nil {
 		return nil, err
 	}
-	return &Number{Value: arg1.Value * arg2.Value}, nil
+	return NewInteger(new(big.Int).GCD(nil, nil, new(big.Int).Abs(arg1), new(big.Int).Abs(arg2))), nil
 }
 
-func (s *Scope) castArithmeticArguments(exprs []Expression) (*Number, *Number, error) {
+// float converts the number to the inexact float, it fails with
+// ErrOverflow, if the number is too large for it.
+func (s *Scope) float(call *Call) (Expression, error) {
+	arg, err := s.castArithmeticArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return checkFloat(toFloat(arg))
+}
+
+// exact converts the number to the exact one, which is equal to it:
+// (exact 0.5) is 1/2.
+func (s *Scope) exact(call *Call) (Expression, error) {
+	arg, err := s.castArithmeticArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := arg.(*Number)
+	if !ok {
+		return arg, nil
+	}
+
+	if math.IsInf(n.Value, 0) || math.IsNaN(n.Value) {
+		return nil, ErrArgumentType{Expected: "finite number", Actual: n.String()}
+	}
+
+	return NewRational(new(big.Rat).SetFloat64(n.Value)), nil
+}
+
+// numerator returns the numerator of the exact number.
+func (s *Scope) numerator(call *Call) (Expression, error) {
+	arg, err := s.castExactArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return NewInteger(new(big.Int).Set(toRat(arg).Num())), nil
+}
+
+// denominator returns the denominator of the exact number, 1 for integers.
+func (s *Scope) denominator(call *Call) (Expression, error) {
+	arg, err := s.castExactArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return NewInteger(new(big.Int).Set(toRat(arg).Denom())), nil
+}
+
+func (s *Scope) castArithmeticArguments(exprs []Expression) (Expression, Expression, error) {
 	if len(exprs) != 2 {
-		return nil, nil, ErrInvalidArguments{expected: "2", actual: len(exprs)}
+		return nil, nil, ErrInvalidArguments{Expected: "2", Actual: len(exprs)}
 	}
 	a, err := s.Eval(exprs[0])
 	if err != nil {
@@ -91,13 +184,52 @@ func (s *Scope) castArithmeticArguments(exprs []Expression) (*Number, *Number, e
 	if err != nil {
 		return nil, nil, err
 	}
-	arg1, ok := a.(*Number)
+	if _, ok := numberKind(a); !ok {
+		return nil, nil, ErrArgumentType{Arg: 1, Expected: "number", Actual: a.Type()}
+	}
+	if _, ok := numberKind(b); !ok {
+		return nil, nil, ErrArgumentType{Arg: 2, Expected: "number", Actual: b.Type()}
+	}
+	return a, b, nil
+}
+
+func (s *Scope) castArithmeticArgument(exprs []Expression) (Expression, error) {
+	if len(exprs) != 1 {
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(exprs)}
+	}
+	a, err := s.Eval(exprs[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := numberKind(a); !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "number", Actual: a.Type()}
+	}
+	return a, nil
+}
+
+func (s *Scope) castExactArgument(exprs []Expression) (Expression, error) {
+	a, err := s.castArithmeticArgument(exprs)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := a.(*Number); ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "exact number", Actual: kindName(a)}
+	}
+	return a, nil
+}
+
+func (s *Scope) castIntegerArguments(exprs []Expression) (*big.Int, *big.Int, error) {
+	a, b, err := s.castArithmeticArguments(exprs)
+	if err != nil {
+		return nil, nil, err
+	}
+	arg1, ok := a.(*Integer)
 	if !ok {
-		return nil, nil, ErrArgumentType{expected: "number", actual: a.Type()}
+		return nil, nil, ErrArgumentType{Arg: 1, Expected: "integer", Actual: kindName(a)}
 	}
-	arg2, ok := b.(*Number)
+	arg2, ok := b.(*Integer)
 	if !ok {
-		return nil, nil, ErrArgumentType{expected: "number", actual: b.Type()}
+		return nil, nil, ErrArgumentType{Arg: 2, Expected: "integer", Actual: kindName(b)}
 	}
-	return arg1, arg2, nil
+	return arg1.big(), arg2.big(), nil
 }
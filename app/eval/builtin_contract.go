@@ -0,0 +1,113 @@
+package eval
+
+import "fmt"
+
+// SetContracts turns on or off the checks of the contracts of functions and
+// of assertions for the whole program the scope belongs to. They are on by
+// default.
+func (s *Scope) SetContracts(on bool) {
+	s.exec.noContracts = !on
+}
+
+// assert fails with ErrContract, if the condition is false, the message is
+// optional:
+//
+//	(assert (greater n 0) "n must be positive")
+func (s *Scope) assert(call *Call) (Expression, error) {
+	if len(call.Args) != 1 && len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "1 or 2", Actual: len(call.Args)}
+	}
+
+	if s.exec.noContracts {
+		return Null{}, nil
+	}
+
+	ok, err := s.evalGuard(call.Args[0])
+	if err != nil || ok {
+		return Null{}, err
+	}
+
+	e := ErrContract{Kind: "assertion", Condition: sourceString(call.Args[0])}
+	if len(call.Args) == 2 {
+		msg, err := s.Eval(call.Args[1])
+		if err != nil {
+			return nil, fmt.Errorf("message: %w", err)
+		}
+		if str, ok := msg.(*String); ok {
+			e.Values = []string{str.Value}
+		} else {
+			e.Values = []string{msg.FString()}
+		}
+	}
+	return nil, e
+}
+
+// checkContract evaluates the conditions in the scope of the call of the
+// function and fails with ErrContract on the first false one.
+func (s *Scope) checkContract(kind string, fn *Closure, conds []Expression, values []string) error {
+	for _, cond := range conds {
+		ok, err := s.evalGuard(cond)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", kind, sourceString(cond), err)
+		}
+		if !ok {
+			return ErrContract{Kind: kind, Function: fn.name(), Condition: sourceString(cond), Values: values}
+		}
+	}
+	return nil
+}
+
+// checkPre checks the preconditions of the function in the scope of its
+// call.
+func (s *Scope) checkPre(fn *Closure, args []Expression) error {
+	if len(fn.Pre) == 0 || s.exec.noContracts {
+		return nil
+	}
+	return s.checkContract("precondition", fn, fn.Pre, argValues(fn, args))
+}
+
+// applyChecked evaluates the body of the function in place, as the result
+// of the call must be checked with the postconditions.
+func (s *Scope) applyChecked(fn *Closure, scope *Scope, frame *Frame) (Expression, error) {
+	if err := s.exec.enter(frame); err != nil {
+		return nil, err
+	}
+	defer s.exec.leave()
+
+	res, err := scope.Eval(fn.Body)
+	if err != nil {
+		return nil, fmt.Errorf("evaluate function %s body: %w", fn.name(), err)
+	}
+
+	post := NewScope("let", scope, s.PrintNulls)
+	post.SetVar("result", res)
+	values := append(argValues(fn, frame.Args), "result = "+res.FString())
+	if err = post.checkContract("postcondition", fn, fn.Post, values); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// argValues returns the arguments of the call, e.g. "x = 1".
+func argValues(fn *Closure, args []Expression) []string {
+	values := make([]string, len(args))
+	for idx, arg := range args {
+		values[idx] = fmt.Sprintf("%s = %s", fn.ArgNames[idx], arg.FString())
+	}
+	return values
+}
+
+// castContract returns the conditions of the contract of the function
+// definition.
+func castContract(pre, post Expression) ([]Expression, []Expression, error) {
+	preList, ok := pre.(*List)
+	if !ok {
+		return nil, nil, fmt.Errorf("preconditions: %w", ErrArgumentType{Expected: "list", Actual: pre.Type()})
+	}
+	postList, ok := post.(*List)
+	if !ok {
+		return nil, nil, fmt.Errorf("postconditions: %w", ErrArgumentType{Expected: "list", Actual: post.Type()})
+	}
+	return preList.Values, postList.Values, nil
+}
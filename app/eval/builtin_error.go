@@ -0,0 +1,191 @@
+package eval
+
+import "fmt"
+
+// throw raises the value, up to the nearest try, which catches it. The
+// error value is raised as it is, any other value is the data of the
+// error of the kind "throw":
+//
+//	(throw 'not-found)
+func (s *Scope) throw(call *Call) (Expression, error) {
+	if len(call.Args) != 1 {
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
+	}
+
+	val, err := s.Eval(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if e, ok := val.(*Error); ok {
+		return nil, e
+	}
+
+	msg := val.FString()
+	if str, ok := val.(*String); ok {
+		msg = str.Value
+	}
+	return nil, &Error{Kind: "throw", Message: msg, Data: val}
+}
+
+// try evaluates the body and, if it fails, the handler of the catch
+// clause with the error bound to the name. The finally clause is evaluated
+// in any case, after the body and the handler:
+//
+//	(try
+//	    (divide 1 x)
+//	    (catch e (print (error-message e)) 0)
+//	    (finally (print "done")))
+//
+// Both clauses are optional. The limits of the evaluation and its timeout
+// can't be caught.
+func (s *Scope) try(call *Call) (Expression, error) {
+	body, catch, finally, err := castTry(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := NewScope("let", s, s.PrintNulls).evalProtected(body)
+	if err != nil && catch != nil {
+		if e, ok := toError(err); ok {
+			scope := NewScope("let", s, s.PrintNulls)
+			scope.SetVar(catch.Args[0].(*Identifier).Name, e)
+			res, err = scope.evalProtected(catch.Args[1:])
+		}
+	}
+
+	if finally != nil {
+		fin, ferr := NewScope("let", s, s.PrintNulls).evalProtected(finally.Args)
+		if ferr != nil {
+			return nil, fmt.Errorf("finally: %w", ferr)
+		}
+		if _, ok := fin.(*tailCall); ok {
+			return fin, nil
+		}
+	}
+
+	return res, err
+}
+
+// evalProtected evaluates the expressions one by one and returns the value
+// of the last one. Unlike evalBody it evaluates the last expression in
+// place, and the value of return as well, so that their errors are caught.
+func (s *Scope) evalProtected(exprs []Expression) (Expression, error) {
+	var res Expression = Null{}
+	for idx, expr := range exprs {
+		var err error
+		if res, err = s.evalTail(expr, true); err != nil {
+			return nil, fmt.Errorf("evaluate expression %d: %w", idx, err)
+		}
+
+		if tail, ok := res.(*tailCall); ok {
+			val, err := tail.scope.Eval(tail.expr)
+			if err != nil {
+				return nil, fmt.Errorf("evaluate expression %d: %w", idx, err)
+			}
+			return &tailCall{expr: val, scope: tail.scope, ret: true}, nil
+		}
+
+		if s.interrupted() {
+			return Null{}, nil
+		}
+	}
+	return res, nil
+}
+
+// castTry returns the body of try and its catch and finally clauses, which
+// go last, in this order.
+func castTry(args []Expression) (body []Expression, catch, finally *Call, err error) {
+	body = args
+	if clause, ok := lastClause(body, "finally"); ok {
+		finally, body = clause, body[:len(body)-1]
+	}
+	if clause, ok := lastClause(body, "catch"); ok {
+		catch, body = clause, body[:len(body)-1]
+		if len(catch.Args) < 1 {
+			return nil, nil, nil, ErrInvalidClause{Form: "try", Index: len(body), Reason: "expected (catch name expr...)"}
+		}
+		if _, ok := catch.Args[0].(*Identifier); !ok {
+			return nil, nil, nil, ErrInvalidClause{Form: "try", Index: len(body), Reason: "expected (catch name expr...)"}
+		}
+	}
+
+	for idx, expr := range body {
+		if c, ok := expr.(*Call); ok && (c.Name == "catch" || c.Name == "finally") {
+			return nil, nil, nil, ErrInvalidClause{Form: "try", Index: idx, Reason: c.Name + " must go after the body"}
+		}
+	}
+
+	if len(body) == 0 {
+		return nil, nil, nil, ErrInvalidArguments{Expected: "at least 1 body", Actual: 0}
+	}
+
+	return body, catch, finally, nil
+}
+
+func lastClause(exprs []Expression, name string) (*Call, bool) {
+	if len(exprs) == 0 {
+		return nil, false
+	}
+	c, ok := exprs[len(exprs)-1].(*Call)
+	return c, ok && c.Name == name
+}
+
+// makeError returns the error value of the kind, message and, optionally,
+// data, which may be thrown:
+//
+//	(throw (error 'not-found "no such user" id))
+func (s *Scope) makeError(call *Call) (Expression, error) {
+	if len(call.Args) != 2 && len(call.Args) != 3 {
+		return nil, ErrInvalidArguments{Expected: "2 or 3", Actual: len(call.Args)}
+	}
+
+	args, err := s.evalArgs(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	kind, ok := args[0].(*Symbol)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "symbol", Actual: args[0].Type()}
+	}
+
+	msg, ok := args[1].(*String)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 2, Expected: "string", Actual: args[1].Type()}
+	}
+
+	var data Expression = Null{}
+	if len(args) == 3 {
+		data = args[2]
+	}
+
+	return &Error{Kind: kind.Name, Message: msg.Value, Data: data}, nil
+}
+
+// errorField returns the builtin, which returns the field of the error
+// value.
+func errorField(field func(*Error) Expression) func(*Scope, *Call) (Expression, error) {
+	return func(s *Scope, call *Call) (Expression, error) {
+		if len(call.Args) != 1 {
+			return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
+		}
+
+		val, err := s.Eval(call.Args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		e, ok := val.(*Error)
+		if !ok {
+			return nil, ErrArgumentType{Expected: "error", Actual: val.Type()}
+		}
+		return field(e), nil
+	}
+}
+
+func errorKind(e *Error) Expression { return &Symbol{Name: e.Kind} }
+
+func errorMessage(e *Error) Expression { return &String{Value: e.Message} }
+
+func errorData(e *Error) Expression { return e.data() }
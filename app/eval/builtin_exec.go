@@ -2,9 +2,23 @@ package eval
 
 import "fmt"
 
+// cond evaluates either the if-then-else form:
+//
+//	(cond (predicate) (then) (else))
+//
+// or the multi-clause one, where the body of the first clause with a true
+// test is evaluated:
+//
+//	(cond ((less x 0) (minus 0 x)) ((equal x 0) 0) (else x))
 func (s *Scope) cond(call *Call) (Expression, error) {
+	if len(call.Args) > 0 {
+		if _, ok := call.Args[0].(*List); ok {
+			return s.condClauses(call.Args)
+		}
+	}
+
 	if len(call.Args) < 2 || len(call.Args) > 3 {
-		return nil, ErrInvalidArguments{expected: "2 or 3", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "2 or 3", Actual: len(call.Args)}
 	}
 
 	predicate, err := s.Eval(call.Args[0])
@@ -14,42 +28,158 @@ func (s *Scope) cond(call *Call) (Expression, error) {
 
 	b, ok := predicate.(*Boolean)
 	if !ok {
-		return nil, ErrArgumentType{expected: "boolean", actual: predicate.Type()}
+		return nil, ErrArgumentType{Expected: "boolean", Actual: predicate.Type()}
 	}
 
 	if b.Value {
-		return s.Eval(call.Args[1])
+		return &tailCall{expr: call.Args[1], scope: s}, nil
 	}
 
 	if len(call.Args) == 3 {
-		return s.Eval(call.Args[2])
+		return &tailCall{expr: call.Args[2], scope: s}, nil
 	}
 
 	return Null{}, nil
 }
 
-func (s *Scope) while(call *Call) (Expression, error) {
-	if len(call.Args) != 2 {
-		return nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+func (s *Scope) condClauses(clauses []Expression) (Expression, error) {
+	for idx, expr := range clauses {
+		clause, ok := expr.(*List)
+		if !ok || len(clause.Values) == 0 {
+			return nil, ErrInvalidClause{Form: "cond", Index: idx, Reason: "expected (test expr...)"}
+		}
+
+		if isElse(clause.Values[0]) {
+			if idx != len(clauses)-1 {
+				return nil, ErrInvalidClause{Form: "cond", Index: idx, Reason: "else must be the last clause"}
+			}
+			return s.evalBody(clause.Values[1:])
+		}
+
+		predicate, err := s.Eval(clause.Values[0])
+		if err != nil {
+			return nil, fmt.Errorf("clause %d: %w", idx, err)
+		}
+
+		b, ok := predicate.(*Boolean)
+		if !ok {
+			return nil, fmt.Errorf("clause %d: %w", idx, ErrArgumentType{Expected: "boolean", Actual: predicate.Type()})
+		}
+
+		if !b.Value {
+			continue
+		}
+
+		if len(clause.Values) == 1 {
+			return b, nil
+		}
+
+		return s.evalBody(clause.Values[1:])
 	}
 
-	predicate, err := s.Eval(call.Args[0])
+	return Null{}, nil
+}
+
+// selectCase evaluates the key and then the body of the first clause, which
+// lists a datum equal to it:
+//
+//	(case (head l) (0 10) ((1 2 3) 20) (else 30))
+func (s *Scope) selectCase(call *Call) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	key, err := s.Eval(call.Args[0])
 	if err != nil {
 		return nil, err
 	}
 
-	b, ok := predicate.(*Boolean)
-	if !ok {
-		return nil, ErrArgumentType{expected: "boolean", actual: predicate.Type()}
-	}
+	clauses := call.Args[1:]
+	for idx, expr := range clauses {
+		clause, ok := expr.(*List)
+		if !ok || len(clause.Values) == 0 {
+			return nil, ErrInvalidClause{Form: "case", Index: idx, Reason: "expected ((datum...) expr...)"}
+		}
+
+		if isElse(clause.Values[0]) {
+			if idx != len(clauses)-1 {
+				return nil, ErrInvalidClause{Form: "case", Index: idx, Reason: "else must be the last clause"}
+			}
+			return s.evalBody(clause.Values[1:])
+		}
+
+		data, ok := clause.Values[0].(*List)
+		if !ok {
+			return nil, ErrInvalidClause{Form: "case", Index: idx, Reason: "expected list of data"}
+		}
 
-	for b.Value && s.Return == nil {
-		return s.Eval(call.Args[1])
+		for _, datum := range data.Values {
+			if _, ok := datum.(*Call); ok {
+				return nil, ErrInvalidClause{Form: "case", Index: idx, Reason: "data must be literals"}
+			}
+			if datum.Equal(key) {
+				return s.evalBody(clause.Values[1:])
+			}
+		}
 	}
 
 	return Null{}, nil
 }
 
+func isElse(expr Expression) bool {
+	id, ok := expr.(*Identifier)
+	return ok && id.Name == "else"
+}
+
+// while evaluates the body until the predicate turns false, the predicate
+// is evaluated before each iteration. The loop has its own scope, which
+// doesn't hide the variables of the enclosing one:
+//
+//	(while (less x 10) (setq x (plus x 1)) (print x))
+//
+// (break) stops the loop, (continue) skips the rest of the body, both of
+// them may be nested in other blocks of the body, e.g. prog or cond.
+func (s *Scope) while(call *Call) (Expression, error) {
+	if len(call.Args) < 2 {
+		return nil, ErrInvalidArguments{Expected: "at least 2", Actual: len(call.Args)}
+	}
+
+	scope := NewScope("loop", s, s.PrintNulls)
+
+	for {
+		if err := s.exec.done(); err != nil {
+			return nil, err
+		}
+
+		predicate, err := scope.Eval(call.Args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		b, ok := predicate.(*Boolean)
+		if !ok {
+			return nil, ErrArgumentType{Expected: "boolean", Actual: predicate.Type()}
+		}
+
+		if !b.Value {
+			return Null{}, nil
+		}
+
+		res, err := scope.evalStatements(call.Args[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := res.(*tailCall); ok {
+			return res, nil
+		}
+
+		if !scope.nextIteration() {
+			return Null{}, nil
+		}
+	}
+}
+
 func (s *Scope) brk(*Call) (Expression, error) {
 	if err := s.SetBreak(); err != nil {
 		return nil, err
@@ -57,26 +187,34 @@ func (s *Scope) brk(*Call) (Expression, error) {
 	return Null{}, nil
 }
 
+func (s *Scope) cont(*Call) (Expression, error) {
+	if err := s.SetContinue(); err != nil {
+		return nil, err
+	}
+	return Null{}, nil
+}
+
 func (s *Scope) ret(call *Call) (Expression, error) {
 	if len(call.Args) > 1 {
-		return nil, ErrInvalidArguments{expected: "0 or 1", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "0 or 1", Actual: len(call.Args)}
+	}
+	if s.funcScope() == nil {
+		return nil, ErrInvalidContext
 	}
+
+	// the value is evaluated after the blocks of the function are left, so
+	// that a tail call in it runs in constant stack
+	var expr Expression = Null{}
 	if len(call.Args) == 1 {
-		expr, err := s.Eval(call.Args[0])
-		if err != nil {
-			return nil, err
-		}
-		if err = s.SetReturn(expr); err != nil {
-			return nil, err
-		}
+		expr = call.Args[0]
 	}
 
-	return Null{}, nil
+	return &tailCall{expr: expr, scope: s, ret: true}, nil
 }
 
 func (s *Scope) Print(call *Call) (Expression, error) {
 	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
 	}
 
 	expr, err := s.Eval(call.Args[0])
@@ -90,27 +228,82 @@ func (s *Scope) Print(call *Call) (Expression, error) {
 		}
 		return Null{}, nil
 	}
+	if str, ok := expr.(*String); ok {
+		fmt.Println(str.Value)
+		return Null{}, nil
+	}
 	fmt.Println(expr.FString())
 	return Null{}, nil
 }
 
+// lambda returns the anonymous function, which captures the current scope:
+// its body sees the variables visible at the place the lambda was
+// evaluated, even after the scope is left.
 func (s *Scope) lambda(call *Call) (Expression, error) {
-	return call, nil
+	argNames, params, body, err := makeLambdaFunc(call)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range argNames {
+		s.exec.redefine(name)
+	}
+	return &Closure{ArgNames: argNames, Params: params, Body: body, Scope: s}, nil
+}
+
+// funcall calls the function with the rest of arguments:
+//
+//	(funcall (lambda (x y) (plus x y)) 1 2)
+func (s *Scope) funcall(call *Call) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	args, err := s.evalArgs(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.applyTail(args[0], args[1:])
+}
+
+// apply calls the function with the elements of the list as arguments:
+//
+//	(apply plus '(1 2))
+func (s *Scope) apply(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	args, err := s.evalArgs(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := args[1].(Null); ok {
+		return s.applyTail(args[0], nil)
+	}
+
+	list, ok := args[1].(*List)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 2, Expected: "list", Actual: args[1].Type()}
+	}
+
+	return s.applyTail(args[0], list.Values)
 }
 
 func (s *Scope) prog(call *Call) (Expression, error) {
 	if len(call.Args) != 2 {
-		return nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
 	}
 
 	exposureListExpr, ok := call.Args[0].(*List)
 	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[0].Type()}
+		return nil, ErrArgumentType{Arg: 1, Expected: "list", Actual: call.Args[0].Type()}
 	}
 
 	bodyExpr, ok := call.Args[1].(*List)
 	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[1].Type()}
+		return nil, ErrArgumentType{Arg: 2, Expected: "list", Actual: call.Args[1].Type()}
 	}
 
 	scope := NewScope("prog", s, s.PrintNulls)
@@ -118,7 +311,7 @@ func (s *Scope) prog(call *Call) (Expression, error) {
 	for _, expr := range exposureListExpr.Values {
 		id, ok := expr.(*Identifier)
 		if !ok {
-			return nil, ErrArgumentType{expected: "identifier", actual: expr.Type()}
+			return nil, ErrArgumentType{Expected: "identifier", Actual: expr.Type()}
 		}
 
 		v, err := s.GetVar(id.Name, true)
@@ -129,29 +322,12 @@ func (s *Scope) prog(call *Call) (Expression, error) {
 		scope.SetVar(id.Name, v)
 	}
 
-	for idx, expr := range bodyExpr.Values {
-		if _, err := scope.Eval(expr); err != nil {
-			return nil, fmt.Errorf("evaluate expression %d: %w", idx, err)
-		}
-		callExpr, ok := expr.(*Call)
-		if !ok {
-			continue
-		}
-		if callExpr.Name == "return" {
-			break
-		}
-	}
-
-	if scope.Return == nil {
-		return Null{}, nil
-	}
-
-	return scope.Return, nil
+	return scope.evalStatements(bodyExpr.Values)
 }
 
 func (s *Scope) eval(call *Call) (Expression, error) {
 	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
 	}
 
 	expr, err := s.Eval(call.Args[0])
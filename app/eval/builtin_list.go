@@ -1,8 +1,10 @@
 package eval
 
+import "fmt"
+
 func (s *Scope) cons(call *Call) (Expression, error) {
 	if len(call.Args) != 2 {
-		return nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
 	}
 
 	elemExpr, err := s.Eval(call.Args[0])
@@ -10,69 +12,331 @@ func (s *Scope) cons(call *Call) (Expression, error) {
 		return nil, err
 	}
 
-	listExpr, err := s.Eval(call.Args[1])
+	list, err := s.evalList(call.Args[1])
 	if err != nil {
 		return nil, err
 	}
 
-	if _, ok := listExpr.(Null); ok {
-		return &List{Values: []Expression{elemExpr}}, nil
+	return s.newList(append([]Expression{elemExpr}, list.Values...))
+}
+
+// tail returns the list without its first element, it fails on an empty
+// list.
+func (s *Scope) tail(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
+	if err != nil {
+		return nil, err
 	}
+	if len(list.Values) == 0 {
+		return nil, ErrEmptyList
+	}
+	return &List{Values: list.Values[1:]}, nil
+}
 
-	list, ok := listExpr.(*List)
-	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: listExpr.Type()}
+// head returns the first element of the list, it fails on an empty list.
+func (s *Scope) head(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Values) == 0 {
+		return nil, ErrEmptyList
 	}
-	return &List{Values: append([]Expression{elemExpr}, list.Values...)}, nil
+	return list.Values[0], nil
 }
 
-func (s *Scope) tail(call *Call) (Expression, error) {
-	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+// last returns the last element of the list, it fails on an empty list.
+func (s *Scope) last(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
+	if err != nil {
+		return nil, err
 	}
+	if len(list.Values) == 0 {
+		return nil, ErrEmptyList
+	}
+	return list.Values[len(list.Values)-1], nil
+}
 
-	expr, err := s.Eval(call.Args[0])
+func (s *Scope) empty(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
 	if err != nil {
 		return nil, err
 	}
+	return &Boolean{Value: len(list.Values) == 0}, nil
+}
 
-	list, ok := expr.(*List)
-	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[0].Type()}
+func (s *Scope) length(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
+	if err != nil {
+		return nil, err
 	}
-	return &List{Values: list.Values[1:]}, nil
+	return &Integer{Value: int64(len(list.Values))}, nil
 }
 
-func (s *Scope) head(call *Call) (Expression, error) {
-	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+// nth returns the element of the list at the index, counting from 0:
+//
+//	(nth 1 '(1 2 3)) // 2
+func (s *Scope) nth(call *Call) (Expression, error) {
+	list, idx, err := s.castIndexAndList(call.Args)
+	if err != nil {
+		return nil, err
 	}
+	if idx >= len(list.Values) {
+		return nil, fmt.Errorf("index %d of %d elements: %w", idx, len(list.Values), ErrOutOfRange)
+	}
+	return list.Values[idx], nil
+}
 
-	expr, err := s.Eval(call.Args[0])
+// take returns the first n elements of the list, or all of them, if there
+// are less:
+//
+//	(take 2 '(1 2 3)) // (1 2)
+func (s *Scope) take(call *Call) (Expression, error) {
+	list, n, err := s.castIndexAndList(call.Args)
 	if err != nil {
 		return nil, err
 	}
+	if n > len(list.Values) {
+		n = len(list.Values)
+	}
+	return &List{Values: list.Values[:n]}, nil
+}
 
-	list, ok := expr.(*List)
-	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[0].Type()}
+// drop returns the list without its first n elements.
+func (s *Scope) drop(call *Call) (Expression, error) {
+	list, n, err := s.castIndexAndList(call.Args)
+	if err != nil {
+		return nil, err
 	}
-	return list.Values[0], nil
+	if n > len(list.Values) {
+		n = len(list.Values)
+	}
+	return &List{Values: list.Values[n:]}, nil
 }
 
-func (s *Scope) empty(call *Call) (Expression, error) {
-	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+// appendLists returns the list of the elements of all lists in order:
+//
+//	(append '(1 2) '(3) '(4 5))
+func (s *Scope) appendLists(call *Call) (Expression, error) {
+	var values []Expression
+	for idx, expr := range call.Args {
+		list, err := s.evalList(expr)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
+		}
+		values = append(values, list.Values...)
+	}
+	return s.newList(values)
+}
+
+func (s *Scope) reverse(call *Call) (Expression, error) {
+	list, err := s.castListArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Expression, len(list.Values))
+	for idx, val := range list.Values {
+		values[len(values)-1-idx] = val
+	}
+	return s.newList(values)
+}
+
+// rangeList returns the list of numbers from start, 0 if omitted, up to,
+// but not including, end, with the given step, 1 if omitted:
+//
+//	(range 5)         // (0 1 2 3 4)
+//	(range 1 10 3)    // (1 4 7)
+func (s *Scope) rangeList(call *Call) (Expression, error) {
+	if len(call.Args) < 1 || len(call.Args) > 3 {
+		return nil, ErrInvalidArguments{Expected: "1 to 3", Actual: len(call.Args)}
+	}
+
+	bounds := make([]Expression, len(call.Args))
+	for idx, arg := range call.Args {
+		n, err := s.evalNumber(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
+		}
+		bounds[idx] = n
+	}
+
+	var start, step Expression = &Integer{}, &Integer{Value: 1}
+	end := bounds[0]
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) == 3 {
+		step = bounds[2]
+	}
+
+	if isZero(step) {
+		return nil, ErrZeroStep
+	}
+
+	var values []Expression
+	for next := numbers(start, end, step); ; {
+		val, ok := next()
+		if !ok {
+			break
+		}
+		if err := s.exec.alloc(1); err != nil {
+			return nil, err
+		}
+		values = append(values, val)
+	}
+	return &List{Values: values}, nil
+}
+
+// zip returns the list of pairs of the elements of both lists at the same
+// positions, as long as the shorter one:
+//
+//	(zip '(1 2 3) '(4 5)) // ((1 4) (2 5))
+func (s *Scope) zip(call *Call) (Expression, error) {
+	a, b, err := s.castListArguments(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(a.Values)
+	if len(b.Values) < n {
+		n = len(b.Values)
+	}
+
+	values := make([]Expression, n)
+	for idx := range values {
+		values[idx] = &List{Values: []Expression{a.Values[idx], b.Values[idx]}}
+	}
+	return s.newList(values)
+}
+
+// member reports whether the list has an element equal to the value.
+func (s *Scope) member(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	val, err := s.Eval(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.evalList(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	for _, elem := range list.Values {
+		if elem.Equal(val) {
+			return &Boolean{Value: true}, nil
+		}
+	}
+	return &Boolean{Value: false}, nil
+}
+
+// assoc returns the first list of the association list, which head equals
+// the key, or null if there is none:
+//
+//	(assoc 2 (cons '(1 10) (cons '(2 20) null))) // (2 20)
+func (s *Scope) assoc(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	key, err := s.Eval(call.Args[0])
+	if err != nil {
+		return nil, err
 	}
 
-	expr, err := s.Eval(call.Args[0])
+	list, err := s.evalList(call.Args[1])
 	if err != nil {
 		return nil, err
 	}
 
-	list, ok := expr.(*List)
+	for idx, elem := range list.Values {
+		pair, ok := elem.(*List)
+		if !ok {
+			return nil, fmt.Errorf("element %d: %w", idx, ErrArgumentType{Expected: "list", Actual: elem.Type()})
+		}
+		if len(pair.Values) > 0 && pair.Values[0].Equal(key) {
+			return pair, nil
+		}
+	}
+	return Null{}, nil
+}
+
+// newList returns the list of the values, accounting them as allocated.
+func (s *Scope) newList(values []Expression) (Expression, error) {
+	if err := s.exec.alloc(len(values)); err != nil {
+		return nil, err
+	}
+	return &List{Values: values}, nil
+}
+
+// evalList evaluates the expression to a list, null is taken for an empty
+// list.
+func (s *Scope) evalList(expr Expression) (*List, error) {
+	val, err := s.Eval(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch val := val.(type) {
+	case *List:
+		return val, nil
+	case Null:
+		return &List{}, nil
+	}
+	return nil, ErrArgumentType{Expected: "list", Actual: val.Type()}
+}
+
+func (s *Scope) castListArgument(exprs []Expression) (*List, error) {
+	if len(exprs) != 1 {
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(exprs)}
+	}
+	return s.evalList(exprs[0])
+}
+
+func (s *Scope) castListArguments(exprs []Expression) (*List, *List, error) {
+	if len(exprs) != 2 {
+		return nil, nil, ErrInvalidArguments{Expected: "2", Actual: len(exprs)}
+	}
+	a, err := s.evalList(exprs[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := s.evalList(exprs[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+// castIndexAndList returns the non-negative integer index and the list,
+// which goes last, as it does for all list functions.
+func (s *Scope) castIndexAndList(exprs []Expression) (*List, int, error) {
+	if len(exprs) != 2 {
+		return nil, 0, ErrInvalidArguments{Expected: "2", Actual: len(exprs)}
+	}
+
+	val, err := s.Eval(exprs[0])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, ok := val.(*Integer)
 	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[0].Type()}
+		return nil, 0, ErrArgumentType{Arg: 1, Expected: "integer", Actual: kindName(val)}
 	}
-	return &Boolean{Value: len(list.Values) == 0}, nil
+
+	if n.Big != nil || n.Value < 0 {
+		return nil, 0, fmt.Errorf("index %s: %w", n, ErrOutOfRange)
+	}
+
+	list, err := s.evalList(exprs[1])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return list, int(n.Value), nil
 }
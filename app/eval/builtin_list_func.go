@@ -0,0 +1,206 @@
+package eval
+
+import (
+	"fmt"
+	"sort"
+)
+
+// mapList returns the list of the results of the function applied to each
+// element of the list:
+//
+//	(map (lambda (x) (times x 2)) '(1 2 3)) // (2 4 6)
+func (s *Scope) mapList(call *Call) (Expression, error) {
+	fn, list, err := s.castFuncAndList(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Expression, len(list.Values))
+	for idx, elem := range list.Values {
+		if values[idx], err = s.Apply(fn, []Expression{elem}); err != nil {
+			return nil, fmt.Errorf("element %d: %w", idx, err)
+		}
+	}
+	return s.newList(values)
+}
+
+// filter returns the list of the elements, for which the predicate is
+// true.
+func (s *Scope) filter(call *Call) (Expression, error) {
+	fn, list, err := s.castFuncAndList(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []Expression
+	for idx, elem := range list.Values {
+		ok, err := s.applyPredicate(fn, elem)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", idx, err)
+		}
+		if ok {
+			values = append(values, elem)
+		}
+	}
+	return s.newList(values)
+}
+
+// foldl combines the elements from left to right, calling the function
+// with the accumulated value and the element:
+//
+//	(foldl minus 10 '(1 2 3)) // ((10 - 1) - 2) - 3 = 4
+func (s *Scope) foldl(call *Call) (Expression, error) {
+	fn, acc, list, err := s.castFold(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx, elem := range list.Values {
+		if acc, err = s.Apply(fn, []Expression{acc, elem}); err != nil {
+			return nil, fmt.Errorf("element %d: %w", idx, err)
+		}
+	}
+	return acc, nil
+}
+
+// foldr combines the elements from right to left, calling the function
+// with the element and the accumulated value:
+//
+//	(foldr minus 10 '(1 2 3)) // 1 - (2 - (3 - 10)) = -8
+func (s *Scope) foldr(call *Call) (Expression, error) {
+	fn, acc, list, err := s.castFold(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := len(list.Values) - 1; idx >= 0; idx-- {
+		if acc, err = s.Apply(fn, []Expression{list.Values[idx], acc}); err != nil {
+			return nil, fmt.Errorf("element %d: %w", idx, err)
+		}
+	}
+	return acc, nil
+}
+
+// reduce is foldl, which starts with the first element of the list, it
+// fails on an empty list.
+func (s *Scope) reduce(call *Call) (Expression, error) {
+	fn, list, err := s.castFuncAndList(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list.Values) == 0 {
+		return nil, ErrEmptyList
+	}
+
+	acc := list.Values[0]
+	for idx, elem := range list.Values[1:] {
+		if acc, err = s.Apply(fn, []Expression{acc, elem}); err != nil {
+			return nil, fmt.Errorf("element %d: %w", idx+1, err)
+		}
+	}
+	return acc, nil
+}
+
+// sortList returns the list sorted with the comparator, which tells whether
+// its first argument goes before the second one. The sort is stable:
+//
+//	(sort less '(3 1 2)) // (1 2 3)
+func (s *Scope) sortList(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	less, err := s.evalFunc(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.evalList(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	values := append([]Expression(nil), list.Values...)
+	sort.SliceStable(values, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var ok bool
+		ok, err = s.applyPredicate(less, values[i], values[j])
+		return ok
+	})
+	if err != nil {
+		return nil, fmt.Errorf("compare: %w", err)
+	}
+
+	return s.newList(values)
+}
+
+// applyPredicate applies the function, which must return a boolean.
+func (s *Scope) applyPredicate(fn Expression, args ...Expression) (bool, error) {
+	res, err := s.Apply(fn, args)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := res.(*Boolean)
+	if !ok {
+		return false, ErrArgumentType{Expected: "boolean", Actual: res.Type()}
+	}
+	return b.Value, nil
+}
+
+// evalFunc evaluates the expression to a callable value.
+func (s *Scope) evalFunc(expr Expression) (Expression, error) {
+	fn, err := s.Eval(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !isCallable(fn) {
+		return nil, ErrArgumentType{Expected: "function", Actual: fn.Type()}
+	}
+	return fn, nil
+}
+
+func (s *Scope) castFuncAndList(exprs []Expression) (Expression, *List, error) {
+	if len(exprs) != 2 {
+		return nil, nil, ErrInvalidArguments{Expected: "2", Actual: len(exprs)}
+	}
+
+	fn, err := s.evalFunc(exprs[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list, err := s.evalList(exprs[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return fn, list, nil
+}
+
+// castFold returns the function, the initial value and the list of a fold.
+func (s *Scope) castFold(exprs []Expression) (Expression, Expression, *List, error) {
+	if len(exprs) != 3 {
+		return nil, nil, nil, ErrInvalidArguments{Expected: "3", Actual: len(exprs)}
+	}
+
+	fn, err := s.evalFunc(exprs[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	init, err := s.Eval(exprs[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	list, err := s.evalList(exprs[2])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return fn, init, list, nil
+}
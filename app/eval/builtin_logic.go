@@ -1,19 +1,40 @@
 package eval
 
+import "fmt"
+
+// and evaluates the arguments from left to right and stops at the first
+// false one, (and) is true:
+//
+//	(and (not (empty l)) (equal (head l) 1))
 func (s *Scope) and(call *Call) (Expression, error) {
-	arg1, arg2, err := s.castBooleanArguments(call.Args)
-	if err != nil {
-		return nil, err
-	}
-	return &Boolean{Value: arg1.Value && arg2.Value}, nil
+	return s.shortCircuit(call.Args, false)
 }
 
+// or evaluates the arguments from left to right and stops at the first
+// true one, (or) is false.
 func (s *Scope) or(call *Call) (Expression, error) {
-	arg1, arg2, err := s.castBooleanArguments(call.Args)
-	if err != nil {
-		return nil, err
+	return s.shortCircuit(call.Args, true)
+}
+
+// shortCircuit returns stop as soon as an argument evaluates to it, and
+// the opposite if none does.
+func (s *Scope) shortCircuit(exprs []Expression, stop bool) (Expression, error) {
+	for idx, expr := range exprs {
+		val, err := s.Eval(expr)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
+		}
+
+		b, ok := val.(*Boolean)
+		if !ok {
+			return nil, ErrArgumentType{Arg: idx + 1, Expected: "boolean", Actual: val.Type()}
+		}
+
+		if b.Value == stop {
+			return &Boolean{Value: stop}, nil
+		}
 	}
-	return &Boolean{Value: arg1.Value || arg2.Value}, nil
+	return &Boolean{Value: !stop}, nil
 }
 
 func (s *Scope) xor(call *Call) (Expression, error) {
@@ -26,7 +47,7 @@ func (s *Scope) xor(call *Call) (Expression, error) {
 
 func (s *Scope) not(call *Call) (Expression, error) {
 	if len(call.Args) != 1 {
-		return nil, ErrInvalidArguments{expected: "1", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(call.Args)}
 	}
 	expr, err := s.Eval(call.Args[0])
 	if err != nil {
@@ -35,7 +56,7 @@ func (s *Scope) not(call *Call) (Expression, error) {
 
 	arg, ok := expr.(*Boolean)
 	if !ok {
-		return nil, ErrArgumentType{expected: "boolean", actual: expr.Type()}
+		return nil, ErrArgumentType{Expected: "boolean", Actual: expr.Type()}
 	}
 
 	return &Boolean{Value: !arg.Value}, nil
@@ -43,7 +64,7 @@ func (s *Scope) not(call *Call) (Expression, error) {
 
 func (s *Scope) castBooleanArguments(exprs []Expression) (*Boolean, *Boolean, error) {
 	if len(exprs) != 2 {
-		return nil, nil, ErrInvalidArguments{expected: "2", actual: len(exprs)}
+		return nil, nil, ErrInvalidArguments{Expected: "2", Actual: len(exprs)}
 	}
 	a, err := s.Eval(exprs[0])
 	if err != nil {
@@ -55,11 +76,11 @@ func (s *Scope) castBooleanArguments(exprs []Expression) (*Boolean, *Boolean, er
 	}
 	arg1, ok := a.(*Boolean)
 	if !ok {
-		return nil, nil, ErrArgumentType{expected: "number", actual: a.Type()}
+		return nil, nil, ErrArgumentType{Arg: 1, Expected: "boolean", Actual: a.Type()}
 	}
 	arg2, ok := b.(*Boolean)
 	if !ok {
-		return nil, nil, ErrArgumentType{expected: "number", actual: b.Type()}
+		return nil, nil, ErrArgumentType{Arg: 2, Expected: "boolean", Actual: b.Type()}
 	}
 	return arg1, arg2, nil
 }
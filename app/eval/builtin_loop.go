@@ -0,0 +1,190 @@
+package eval
+
+import "fmt"
+
+// forEach evaluates the body for each element of the list:
+//
+//	(for-each (x '(1 2 3)) (print x))
+func (s *Scope) forEach(call *Call) (Expression, error) {
+	name, args, err := castLoopSpec(call, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := s.Eval(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := expr.(Null); ok {
+		return Null{}, nil
+	}
+
+	list, ok := expr.(*List)
+	if !ok {
+		return nil, ErrArgumentType{Expected: "list", Actual: expr.Type()}
+	}
+
+	values := list.Values
+	return s.iterate(name, call.Args[1:], func() (Expression, bool) {
+		if len(values) == 0 {
+			return nil, false
+		}
+		val := values[0]
+		values = values[1:]
+		return val, true
+	})
+}
+
+// dotimes evaluates the body count times, binding the name to the numbers
+// from 0 to count-1:
+//
+//	(dotimes (i 3) (print i))
+func (s *Scope) dotimes(call *Call) (Expression, error) {
+	name, args, err := castLoopSpec(call, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.evalNumber(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.iterate(name, call.Args[1:], numbers(&Integer{}, count, &Integer{Value: 1}))
+}
+
+// forRange evaluates the body for the numbers from start up to, but not
+// including, end, with the given step, 1 if omitted. With a negative step
+// the numbers go down to end:
+//
+//	(for (i 10 0 (minus 0 2)) (print i))
+func (s *Scope) forRange(call *Call) (Expression, error) {
+	name, args, err := castLoopSpec(call, 2, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := make([]Expression, len(args))
+	for idx, arg := range args {
+		n, err := s.evalNumber(arg)
+		if err != nil {
+			return nil, fmt.Errorf("loop bound %d: %w", idx, err)
+		}
+		bounds[idx] = n
+	}
+
+	var step Expression = &Integer{Value: 1}
+	if len(bounds) == 3 {
+		step = bounds[2]
+	}
+
+	if isZero(step) {
+		return nil, ErrZeroStep
+	}
+
+	return s.iterate(name, call.Args[1:], numbers(bounds[0], bounds[1], step))
+}
+
+// iterate evaluates the body for each value returned by next, until it
+// reports there are no more values. Each iteration has a fresh scope with
+// the value bound to the name, so the body may set the variable without
+// affecting the loop.
+func (s *Scope) iterate(name string, body []Expression, next func() (Expression, bool)) (Expression, error) {
+	loop := NewScope("loop", s, s.PrintNulls)
+
+	for {
+		if err := s.exec.done(); err != nil {
+			return nil, err
+		}
+
+		val, ok := next()
+		if !ok {
+			return Null{}, nil
+		}
+
+		scope := NewScope("let", loop, s.PrintNulls)
+		scope.SetVar(name, val)
+
+		res, err := scope.evalStatements(body)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := res.(*tailCall); ok {
+			return res, nil
+		}
+
+		if !loop.nextIteration() {
+			return Null{}, nil
+		}
+	}
+}
+
+// nextIteration resets the continue signal of the loop scope and reports
+// whether the loop must go on with the next iteration, i.e. it wasn't
+// stopped with break.
+func (s *Scope) nextIteration() bool {
+	switch s.Return.(type) {
+	case brk:
+		return false
+	case cont:
+		s.Return = nil
+	}
+	return !s.interrupted()
+}
+
+// numbers returns the generator of numbers from start to end, exclusive.
+func numbers(start, end, step Expression) func() (Expression, bool) {
+	i, dir := start, compareNumbers(step, &Integer{})
+	return func() (Expression, bool) {
+		if compareNumbers(i, end) != -dir {
+			return nil, false
+		}
+		val := i
+		i = opAdd.apply(i, step)
+		return val, true
+	}
+}
+
+func (s *Scope) evalNumber(expr Expression) (Expression, error) {
+	val, err := s.Eval(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := numberKind(val); !ok {
+		return nil, ErrArgumentType{Expected: "number", Actual: val.Type()}
+	}
+
+	return val, nil
+}
+
+// castLoopSpec returns the name of the loop variable and the arguments
+// listed after it in the loop specification, e.g. (i 0 10).
+func castLoopSpec(call *Call, minArgs, maxArgs int) (string, []Expression, error) {
+	if len(call.Args) < 2 {
+		return "", nil, ErrInvalidArguments{Expected: "at least 2", Actual: len(call.Args)}
+	}
+
+	spec, ok := call.Args[0].(*List)
+	if !ok || len(spec.Values) == 0 {
+		return "", nil, ErrArgumentType{Arg: 1, Expected: "loop specification", Actual: call.Args[0].Type()}
+	}
+
+	name, ok := spec.Values[0].(*Identifier)
+	if !ok {
+		return "", nil, ErrArgumentType{Expected: "identifier", Actual: spec.Values[0].Type()}
+	}
+
+	args := spec.Values[1:]
+	if len(args) < minArgs || len(args) > maxArgs {
+		expected := fmt.Sprintf("%d to %d", minArgs, maxArgs)
+		if minArgs == maxArgs {
+			expected = fmt.Sprintf("%d", minArgs)
+		}
+		return "", nil, fmt.Errorf("loop specification: %w", ErrInvalidArguments{Expected: expected, Actual: len(args)})
+	}
+
+	return name.Name, args, nil
+}
@@ -0,0 +1,188 @@
+package eval
+
+import "fmt"
+
+// hashmap returns the map of the keys to the values, which go in pairs:
+//
+//	(hashmap 'red 1 'green 2) // {red: 1, green: 2}
+func (s *Scope) hashmap(call *Call) (Expression, error) {
+	if len(call.Args)%2 != 0 {
+		return nil, ErrInvalidArguments{Expected: "even number of", Actual: len(call.Args)}
+	}
+
+	vals, err := s.evalArgs(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, values := make([]Expression, 0, len(vals)/2), make([]Expression, 0, len(vals)/2)
+	for idx := 0; idx < len(vals); idx += 2 {
+		keys, values = append(keys, vals[idx]), append(values, vals[idx+1])
+	}
+
+	if err = s.exec.alloc(len(keys)); err != nil {
+		return nil, err
+	}
+	return NewMap(keys, values)
+}
+
+// mapget returns the value of the key, or the default one, null if it is
+// omitted, when the map has no such key:
+//
+//	(mapget m 'blue 0)
+func (s *Scope) mapget(call *Call) (Expression, error) {
+	if len(call.Args) != 2 && len(call.Args) != 3 {
+		return nil, ErrInvalidArguments{Expected: "2 or 3", Actual: len(call.Args)}
+	}
+
+	m, key, err := s.castMapAndKey(call.Args[:2])
+	if err != nil {
+		return nil, err
+	}
+
+	val, ok, err := m.Get(key)
+	switch {
+	case err != nil:
+		return nil, err
+	case ok:
+		return val, nil
+	case len(call.Args) == 3:
+		return s.Eval(call.Args[2])
+	}
+	return Null{}, nil
+}
+
+// mapput returns the map, where the key has the value, the original map
+// stays the same.
+func (s *Scope) mapput(call *Call) (Expression, error) {
+	if len(call.Args) != 3 {
+		return nil, ErrInvalidArguments{Expected: "3", Actual: len(call.Args)}
+	}
+
+	m, key, err := s.castMapAndKey(call.Args[:2])
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := s.Eval(call.Args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	// the new map shares the rest of values with the original one
+	if err = s.exec.alloc(1); err != nil {
+		return nil, err
+	}
+	return m.Put(key, val)
+}
+
+// mapdel returns the map without the key, the original map stays the same.
+func (s *Scope) mapdel(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	m, key, err := s.castMapAndKey(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Delete(key)
+}
+
+// maphas reports whether the map has the key.
+func (s *Scope) maphas(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	m, key, err := s.castMapAndKey(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ok, err := m.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Boolean{Value: ok}, nil
+}
+
+// mapkeys returns the list of the keys of the map in the order they were
+// put.
+func (s *Scope) mapkeys(call *Call) (Expression, error) {
+	m, err := s.castMapArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return s.newList(m.Keys())
+}
+
+// mapvalues returns the list of the values of the map in the order of
+// their keys.
+func (s *Scope) mapvalues(call *Call) (Expression, error) {
+	m, err := s.castMapArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return s.newList(m.Values())
+}
+
+// mapmerge returns the map with the keys of all maps, the value of a key
+// is taken from the last map, which has it:
+//
+//	(mapmerge defaults options)
+func (s *Scope) mapmerge(call *Call) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	var keys, values []Expression
+	for idx, expr := range call.Args {
+		m, err := s.evalMap(expr)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
+		}
+		keys, values = append(keys, m.Keys()...), append(values, m.Values()...)
+	}
+
+	if err := s.exec.alloc(len(keys)); err != nil {
+		return nil, err
+	}
+	return NewMap(keys, values)
+}
+
+// evalMap evaluates the expression to a map.
+func (s *Scope) evalMap(expr Expression) (*Map, error) {
+	val, err := s.Eval(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := val.(*Map)
+	if !ok {
+		return nil, ErrArgumentType{Expected: "map", Actual: val.Type()}
+	}
+	return m, nil
+}
+
+func (s *Scope) castMapArgument(exprs []Expression) (*Map, error) {
+	if len(exprs) != 1 {
+		return nil, ErrInvalidArguments{Expected: "1", Actual: len(exprs)}
+	}
+	return s.evalMap(exprs[0])
+}
+
+func (s *Scope) castMapAndKey(exprs []Expression) (*Map, Expression, error) {
+	m, err := s.evalMap(exprs[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := s.Eval(exprs[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, key, nil
+}
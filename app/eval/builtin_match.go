@@ -0,0 +1,403 @@
+package eval
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// deftype declares the algebraic data type with its variants. A variant
+// with fields gets the constructor of its name, a variant without fields
+// is the value itself:
+//
+//	(deftype shape (circle r) (rect w h) blank)
+//	(circle 1)      // (circle 1)
+//	(isshape blank) // true
+func (s *Scope) deftype(call *Call) (Expression, error) {
+	if len(call.Args) < 2 {
+		return nil, ErrInvalidArguments{Expected: "at least 2", Actual: len(call.Args)}
+	}
+
+	name, ok := call.Args[0].(*Identifier)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
+	}
+
+	if isBuiltinType(name.Name) {
+		return nil, fmt.Errorf("%s is a builtin type and can't be redefined", name.Name)
+	}
+
+	def := &TypeDef{Name: name.Name}
+	names := map[string]bool{}
+	for idx, expr := range call.Args[1:] {
+		variant, err := parseVariant(expr)
+		if err != nil {
+			return nil, fmt.Errorf("variant %d: %w", idx, err)
+		}
+		if names[variant.Name] {
+			return nil, fmt.Errorf("variant %s is declared twice", variant.Name)
+		}
+		if specialForms[variant.Name] || isPatternKeyword(variant.Name) {
+			return nil, ErrReserved{Name: variant.Name}
+		}
+		names[variant.Name] = true
+		variant.Type = def
+		def.Variants = append(def.Variants, variant)
+	}
+
+	for _, variant := range def.Variants {
+		if len(variant.Fields) == 0 {
+			s.SetVar(variant.Name, &Variant{Def: variant})
+			continue
+		}
+		s.SetVar(variant.Name, &Native{
+			Name:    variant.Name,
+			Arity:   len(variant.Fields),
+			Fn:      variant.construct,
+			variant: variant,
+		})
+	}
+
+	s.SetVar("is"+def.Name, &Native{Name: "is" + def.Name, Arity: 1, Fn: def.is})
+	return Null{}, nil
+}
+
+// parseVariant returns the variant of its declaration: either the name or
+// the call-like list of the name and the fields.
+func parseVariant(expr Expression) (*VariantDef, error) {
+	switch expr := expr.(type) {
+	case *Identifier:
+		return &VariantDef{Name: expr.Name}, nil
+	case *Call:
+		variant := &VariantDef{Name: expr.Name}
+		for idx, arg := range expr.Args {
+			field, ok := arg.(*Identifier)
+			if !ok {
+				return nil, fmt.Errorf("field %d: %w", idx, ErrArgumentType{Expected: "identifier", Actual: arg.Type()})
+			}
+			variant.Fields = append(variant.Fields, field.Name)
+		}
+		return variant, nil
+	}
+	return nil, ErrArgumentType{Expected: "name or (name fields...)", Actual: expr.Type()}
+}
+
+func (d *VariantDef) construct(s *Scope, args []Expression) (Expression, error) {
+	if err := s.exec.alloc(len(args)); err != nil {
+		return nil, err
+	}
+	return &Variant{Def: d, Values: append([]Expression(nil), args...)}, nil
+}
+
+func (d *TypeDef) is(_ *Scope, args []Expression) (Expression, error) {
+	v, ok := args[0].(*Variant)
+	return &Boolean{Value: ok && v.Def.Type == d}, nil
+}
+
+// match evaluates the value and the body of the first clause, which
+// pattern matches it, with the variables of the pattern bound:
+//
+//	(match s
+//	    ((circle r) (times pi (times r r)))
+//	    ((rect w h) (times w h))
+//	    (blank 0))
+//
+// A pattern is one of:
+//   - a literal: a number, string, boolean, null or quoted symbol or list,
+//     which matches the equal value;
+//   - _, which matches any value;
+//   - a name of a variant without fields, which matches the variant;
+//   - any other name, which matches any value and binds it to the name;
+//   - (constructor patterns...), which matches the variant, which fields
+//     match the patterns;
+//   - (list patterns...), which matches the list of as many elements, as
+//     there are patterns, which match them;
+//   - (cons head tail), which matches the non-empty list, which first
+//     element matches head and the rest of elements match tail.
+//
+// The clause may have a guard after its pattern, the clause is taken only
+// if the guard is true with the variables of the pattern bound:
+//
+//	(match l ((cons h _) when (greater h 0) h) (_ 0))
+//
+// It fails with ErrNoMatch, if no pattern matches the value. The match,
+// which patterns are the variants of the same type, but not all of them,
+// is reported once with a warning.
+func (s *Scope) match(call *Call) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	val, err := s.Eval(call.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	clauses := make([]*List, len(call.Args)-1)
+	for idx, expr := range call.Args[1:] {
+		clause, ok := expr.(*List)
+		if !ok || len(clause.Values) == 0 {
+			return nil, ErrInvalidClause{Form: "match", Index: idx, Reason: "expected (pattern expr...)"}
+		}
+		clauses[idx] = clause
+	}
+
+	s.checkExhaustive(call, clauses)
+
+	for idx, clause := range clauses {
+		binds := map[string]Expression{}
+		ok, err := s.matchPattern(clause.Values[0], val, binds)
+		if err != nil {
+			return nil, fmt.Errorf("clause %d: %w", idx, err)
+		}
+		if !ok {
+			continue
+		}
+
+		scope := NewScope("let", s, s.PrintNulls)
+		for name, v := range binds {
+			scope.SetVar(name, v)
+		}
+
+		guard, body := clauseGuard(clause)
+		if guard != nil {
+			if ok, err = scope.evalGuard(guard); err != nil {
+				return nil, fmt.Errorf("clause %d guard: %w", idx, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		return scope.evalBody(body)
+	}
+
+	return nil, fmt.Errorf("%s: %w", val.FString(), ErrNoMatch)
+}
+
+// clauseGuard returns the guard of the clause, if it has one, and its body:
+//
+//	(pattern when guard expr...)
+func clauseGuard(clause *List) (Expression, []Expression) {
+	body := clause.Values[1:]
+	if len(body) < 2 {
+		return nil, body
+	}
+	if id, ok := body[0].(*Identifier); ok && id.Name == "when" {
+		return body[1], body[2:]
+	}
+	return nil, body
+}
+
+func (s *Scope) evalGuard(guard Expression) (bool, error) {
+	val, err := s.Eval(guard)
+	if err != nil {
+		return false, err
+	}
+	b, ok := val.(*Boolean)
+	if !ok {
+		return false, ErrArgumentType{Expected: "boolean", Actual: val.Type()}
+	}
+	return b.Value, nil
+}
+
+// matchPattern reports whether the value matches the pattern and puts the
+// values of the variables of the pattern to binds.
+func (s *Scope) matchPattern(pattern, val Expression, binds map[string]Expression) (bool, error) {
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		if pattern.Name == "_" {
+			return true, nil
+		}
+		if def, _, ok := s.patternVariant(pattern); ok {
+			if len(def.Fields) != 0 {
+				return false, fmt.Errorf("%s has %d fields, got 0: %w", def.Name, len(def.Fields), ErrInvalidPattern)
+			}
+			v, ok := val.(*Variant)
+			return ok && v.Def == def, nil
+		}
+		if _, ok := binds[pattern.Name]; ok {
+			return false, fmt.Errorf("%s is bound twice: %w", pattern.Name, ErrInvalidPattern)
+		}
+		binds[pattern.Name] = val
+		return true, nil
+	case *Call:
+		switch pattern.Name {
+		case "list":
+			return s.matchList(pattern.Args, val, binds)
+		case "cons":
+			return s.matchCons(pattern.Args, val, binds)
+		}
+
+		def, args, ok := s.patternVariant(pattern)
+		if !ok {
+			return false, fmt.Errorf("%s is not a constructor: %w", pattern.Name, ErrInvalidPattern)
+		}
+		if len(args) != len(def.Fields) {
+			return false, fmt.Errorf("%s has %d fields, got %d: %w", def.Name, len(def.Fields), len(args), ErrInvalidPattern)
+		}
+
+		v, ok := val.(*Variant)
+		if !ok || v.Def != def {
+			return false, nil
+		}
+		return s.matchAll(args, v.Values, binds)
+	case *Integer, *Rational, *Number, *String, *Symbol, *Boolean, Null, *List:
+		return pattern.Equal(val), nil
+	}
+	return false, fmt.Errorf("%s: %w", pattern, ErrInvalidPattern)
+}
+
+// matchList matches the list of as many elements as there are patterns,
+// null is taken for an empty list.
+func (s *Scope) matchList(patterns []Expression, val Expression, binds map[string]Expression) (bool, error) {
+	var values []Expression
+	switch val := val.(type) {
+	case *List:
+		values = val.Values
+	case Null:
+	default:
+		return false, nil
+	}
+
+	if len(values) != len(patterns) {
+		return false, nil
+	}
+	return s.matchAll(patterns, values, binds)
+}
+
+// matchCons matches the non-empty list, which head and tail match the
+// patterns.
+func (s *Scope) matchCons(patterns []Expression, val Expression, binds map[string]Expression) (bool, error) {
+	if len(patterns) != 2 {
+		return false, fmt.Errorf("cons has 2 fields, got %d: %w", len(patterns), ErrInvalidPattern)
+	}
+
+	list, ok := val.(*List)
+	if !ok || len(list.Values) == 0 {
+		return false, nil
+	}
+	return s.matchAll(patterns, []Expression{list.Values[0], &List{Values: list.Values[1:]}}, binds)
+}
+
+func (s *Scope) matchAll(patterns, values []Expression, binds map[string]Expression) (bool, error) {
+	for idx, pattern := range patterns {
+		ok, err := s.matchPattern(pattern, values[idx], binds)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// patternVariant returns the variant the pattern refers to, with the
+// patterns of its fields, if the pattern is a variant one.
+func (s *Scope) patternVariant(pattern Expression) (*VariantDef, []Expression, bool) {
+	var (
+		name string
+		args []Expression
+	)
+
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		name = pattern.Name
+	case *Call:
+		name, args = pattern.Name, pattern.Args
+	default:
+		return nil, nil, false
+	}
+
+	if isPatternKeyword(name) {
+		return nil, nil, false
+	}
+
+	switch fn := s.lookup(name).(type) {
+	case *Native:
+		if fn.variant != nil {
+			return fn.variant, args, true
+		}
+	case *Variant:
+		if len(fn.Def.Fields) == 0 {
+			return fn.Def, args, true
+		}
+	}
+	return nil, nil, false
+}
+
+// irrefutable reports whether the pattern matches any value.
+func (s *Scope) irrefutable(pattern Expression) bool {
+	if _, ok := pattern.(*Identifier); !ok {
+		return false
+	}
+	_, _, ok := s.patternVariant(pattern)
+	return !ok
+}
+
+// checkExhaustive warns once about the match, which patterns are the
+// variants of the same type, if some of the variants aren't matched by a
+// pattern without a guard, which matches every value of the variant.
+func (s *Scope) checkExhaustive(call *Call, clauses []*List) {
+	if s.exec.warned[call] {
+		return
+	}
+
+	var typ *TypeDef
+	covered := map[*VariantDef]bool{}
+	for _, clause := range clauses {
+		if guard, _ := clauseGuard(clause); guard != nil {
+			continue
+		}
+
+		pattern := clause.Values[0]
+		if s.irrefutable(pattern) {
+			return
+		}
+
+		def, args, ok := s.patternVariant(pattern)
+		if !ok {
+			continue
+		}
+		if typ != nil && typ != def.Type {
+			return
+		}
+		typ = def.Type
+
+		all := true
+		for _, arg := range args {
+			all = all && s.irrefutable(arg)
+		}
+		covered[def] = covered[def] || all
+	}
+
+	if typ == nil {
+		return
+	}
+
+	var missing []string
+	for _, variant := range typ.Variants {
+		if !covered[variant] {
+			missing = append(missing, variant.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		if s.exec.warned == nil {
+			s.exec.warned = map[*Call]bool{}
+		}
+		s.exec.warned[call] = true
+		log.Printf("[WARN] match on %s is not exhaustive, missing %s", typ.Name, strings.Join(missing, ", "))
+	}
+}
+
+// lookup returns the value of the variable, or nil if it is undefined.
+func (s *Scope) lookup(name string) Expression {
+	v, err := s.GetVar(name, true)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func isPatternKeyword(name string) bool {
+	return name == "_" || name == "list" || name == "cons"
+}
@@ -0,0 +1,280 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+// sqrt returns the square root, which is exact for the squares of exact
+// numbers, e.g. (sqrt 16) is 4.
+func (s *Scope) sqrt(call *Call) (Expression, error) {
+	arg, err := s.castArithmeticArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if compareNumbers(arg, &Integer{}) < 0 {
+		return nil, ErrDomain
+	}
+
+	if _, ok := arg.(*Number); !ok {
+		r := toRat(arg)
+		num, den := new(big.Int).Sqrt(r.Num()), new(big.Int).Sqrt(r.Denom())
+		if new(big.Int).Mul(num, num).Cmp(r.Num()) == 0 && new(big.Int).Mul(den, den).Cmp(r.Denom()) == 0 {
+			return NewRational(new(big.Rat).SetFrac(num, den)), nil
+		}
+	}
+
+	return checkFloat(math.Sqrt(toFloat(arg)))
+}
+
+// maxPowBits is the size in bits of the largest exact power pow computes,
+// as neither the limits nor the context can stop the computation of a power
+// once it started.
+const maxPowBits = 1 << 20
+
+// pow raises the base to the power, the result is exact for an exact base
+// and an integer power. The exact power larger than maxPowBits fails with
+// ErrDomain.
+func (s *Scope) pow(call *Call) (Expression, error) {
+	base, power, err := s.castArithmeticArguments(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := power.(*Integer)
+	if _, inexact := base.(*Number); !ok || inexact {
+		if isZero(base) && compareNumbers(power, &Integer{}) < 0 {
+			return nil, ErrZeroDivision
+		}
+		return checkFloat(math.Pow(toFloat(base), toFloat(power)))
+	}
+
+	exp := n.big()
+	if exp.Sign() < 0 && isZero(base) {
+		return nil, ErrZeroDivision
+	}
+
+	r := toRat(base)
+	if powTooLarge(r, exp) {
+		return nil, ErrDomain
+	}
+	num := new(big.Int).Exp(r.Num(), new(big.Int).Abs(exp), nil)
+	den := new(big.Int).Exp(r.Denom(), new(big.Int).Abs(exp), nil)
+	if exp.Sign() < 0 {
+		num, den = den, num
+	}
+	return NewRational(new(big.Rat).SetFrac(num, den)), nil
+}
+
+// powTooLarge reports whether the power of the base is surely larger than
+// maxPowBits: the power of a number of n bits has at least (n-1)*exp+1.
+func powTooLarge(base *big.Rat, exp *big.Int) bool {
+	bits := base.Num().BitLen()
+	if den := base.Denom().BitLen(); den > bits {
+		bits = den
+	}
+	if bits <= 1 {
+		// the powers of 0, 1 and -1, and of their reciprocals
+		return false
+	}
+	limit := big.NewInt(maxPowBits / int64(bits-1))
+	return new(big.Int).Abs(exp).Cmp(limit) > 0
+}
+
+// abs returns the absolute value of the number of the same kind.
+func (s *Scope) abs(call *Call) (Expression, error) {
+	arg, err := s.castArithmeticArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	if compareNumbers(arg, &Integer{}) < 0 {
+		return opSub.apply(&Integer{}, arg), nil
+	}
+	return arg, nil
+}
+
+// min returns the least of the numbers.
+func (s *Scope) min(call *Call) (Expression, error) {
+	return s.extremum(call, -1)
+}
+
+// max returns the greatest of the numbers.
+func (s *Scope) max(call *Call) (Expression, error) {
+	return s.extremum(call, 1)
+}
+
+// extremum returns the number, which compares to every other one as sign
+// or equal.
+func (s *Scope) extremum(call *Call, sign int) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	var result Expression
+	for idx, expr := range call.Args {
+		val, err := s.Eval(expr)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
+		}
+		if _, ok := numberKind(val); !ok {
+			return nil, ErrArgumentType{Arg: idx + 1, Expected: "number", Actual: val.Type()}
+		}
+		if result == nil || compareNumbers(val, result) == sign {
+			result = val
+		}
+	}
+
+	return result, nil
+}
+
+// rounding returns the builtin, which rounds the number to an integer.
+// Exact numbers are rounded with the function of rationals to an exact
+// integer, floats are rounded to a float. round rounds the halves to the
+// even integer, e.g. (round 2.5) is 2.0 and (round 7/2) is 4.
+func rounding(exact func(*big.Rat) *big.Int, inexact func(float64) float64) func(*Scope, *Call) (Expression, error) {
+	return func(s *Scope, call *Call) (Expression, error) {
+		arg, err := s.castArithmeticArgument(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		if n, ok := arg.(*Number); ok {
+			return &Number{Value: inexact(n.Value)}, nil
+		}
+		return NewInteger(exact(toRat(arg))), nil
+	}
+}
+
+func floorRat(r *big.Rat) *big.Int {
+	// the denominator is positive, so the euclidean division rounds down
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+func ceilRat(r *big.Rat) *big.Int {
+	return new(big.Int).Neg(floorRat(new(big.Rat).Neg(r)))
+}
+
+func truncateRat(r *big.Rat) *big.Int {
+	return new(big.Int).Quo(r.Num(), r.Denom())
+}
+
+// roundRat rounds to the nearest integer, and to the even one of the two
+// nearest, as math.RoundToEven does.
+func roundRat(r *big.Rat) *big.Int {
+	floor := floorRat(r)
+	diff := new(big.Rat).Sub(r, new(big.Rat).SetInt(floor))
+	switch diff.Cmp(big.NewRat(1, 2)) {
+	case -1:
+		return floor
+	case 0:
+		if floor.Bit(0) == 0 {
+			return floor
+		}
+	}
+	return floor.Add(floor, big.NewInt(1))
+}
+
+// float1 returns the builtin, which applies the function to the number
+// converted to a float. The domain, if not nil, reports whether the
+// function is defined for the number.
+func float1(fn func(float64) float64, domain func(float64) bool) func(*Scope, *Call) (Expression, error) {
+	return func(s *Scope, call *Call) (Expression, error) {
+		arg, err := s.castArithmeticArgument(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		x := toFloat(arg)
+		if domain != nil && !domain(x) {
+			return nil, ErrDomain
+		}
+		return checkFloat(fn(x))
+	}
+}
+
+// checkFloat returns the result of the float function, failing with
+// ErrOverflow, if it is infinite, and with ErrDomain, if it is not a
+// number, as math functions return those instead of errors.
+func checkFloat(x float64) (Expression, error) {
+	switch {
+	case math.IsInf(x, 0):
+		return nil, ErrOverflow
+	case math.IsNaN(x):
+		return nil, ErrDomain
+	}
+	return &Number{Value: x}, nil
+}
+
+// checkNumber checks the result of the arithmetic as checkFloat does, if it
+// is a float, the exact numbers can't overflow.
+func checkNumber(x Expression) (Expression, error) {
+	if n, ok := x.(*Number); ok {
+		return checkFloat(n.Value)
+	}
+	return x, nil
+}
+
+func positive(x float64) bool { return x > 0 }
+
+func unit(x float64) bool { return x >= -1 && x <= 1 }
+
+// atan returns the arc tangent of the number, or, with two arguments, the
+// one of y/x, using the signs of both to find the quadrant:
+//
+//	(atan y x)
+func (s *Scope) atan(call *Call) (Expression, error) {
+	if len(call.Args) == 1 {
+		return float1(math.Atan, nil)(s, call)
+	}
+
+	y, x, err := s.castArithmeticArguments(call.Args)
+	if err != nil {
+		return nil, err
+	}
+	return &Number{Value: math.Atan2(toFloat(y), toFloat(x))}, nil
+}
+
+// random returns a float from [0, 1), or, given a number n, a number from
+// [0, n) of the same kind:
+//
+//	(random 6) // an integer from 0 to 5
+func (s *Scope) random(call *Call) (Expression, error) {
+	if len(call.Args) == 0 {
+		return &Number{Value: s.exec.rand().Float64()}, nil
+	}
+
+	arg, err := s.castArithmeticArgument(call.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if compareNumbers(arg, &Integer{}) <= 0 {
+		return nil, ErrDomain
+	}
+
+	n, ok := arg.(*Integer)
+	if !ok {
+		return &Number{Value: s.exec.rand().Float64() * toFloat(arg)}, nil
+	}
+
+	if n.Big == nil {
+		return &Integer{Value: s.exec.rand().Int63n(n.Value)}, nil
+	}
+	return NewInteger(new(big.Int).Rand(s.exec.rand(), n.Big)), nil
+}
+
+// SetSeed seeds the random number generator of the program the scope
+// belongs to, so that the program gives the same random numbers on every
+// run. Otherwise it is seeded with the current time.
+func (s *Scope) SetSeed(seed int64) {
+	s.exec.rnd = rand.New(rand.NewSource(seed))
+}
+
+func (e *execution) rand() *rand.Rand {
+	if e.rnd == nil {
+		e.rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return e.rnd
+}
@@ -4,12 +4,12 @@ import "fmt"
 
 func (s *Scope) setq(call *Call) (Expression, error) {
 	if len(call.Args) != 2 {
-		return nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
 	}
 
 	name, ok := call.Args[0].(*Identifier)
 	if !ok {
-		return nil, ErrArgumentType{expected: "identifier", actual: call.Args[0].Type()}
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
 	}
 
 	val, err := s.Eval(call.Args[1])
@@ -17,35 +17,262 @@ func (s *Scope) setq(call *Call) (Expression, error) {
 		return nil, err
 	}
 
-	s.SetVar(name.Name, val)
+	if err = s.assign(name.Name, val); err != nil {
+		return nil, err
+	}
+
+	return Null{}, nil
+}
+
+// set updates the nearest binding of the variable, in the current scope or
+// in any enclosing one, and fails with ErrUndefined, if there is none:
+//
+//	(set! count (plus count 1))
+func (s *Scope) set(call *Call) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	name, ok := call.Args[0].(*Identifier)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
+	}
+
+	val, err := s.Eval(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.update(name.Name, val); err != nil {
+		return nil, err
+	}
+
+	return Null{}, nil
+}
+
+// define binds the name to the value in the current scope, unlike setq it
+// fails if the scope has the name bound already:
+//
+//	(define total 0)
+func (s *Scope) define(call *Call) (Expression, error) {
+	return s.declareVar(call, false)
+}
+
+// constant binds the name to the value in the current scope as define
+// does, the variable can't be assigned afterwards:
+//
+//	(const limit 10)
+func (s *Scope) constant(call *Call) (Expression, error) {
+	return s.declareVar(call, true)
+}
+
+func (s *Scope) declareVar(call *Call, constant bool) (Expression, error) {
+	if len(call.Args) != 2 {
+		return nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
+	}
+
+	name, ok := call.Args[0].(*Identifier)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
+	}
+
+	val, err := s.Eval(call.Args[1])
+	if err != nil {
+		return nil, err
+	}
 
+	if err = s.declare(name.Name, val, constant); err != nil {
+		return nil, err
+	}
 	return Null{}, nil
 }
 
 func (s *Scope) setfn(call *Call) (Expression, error) {
-	if len(call.Args) != 3 {
-		return nil, ErrInvalidArguments{expected: "3", actual: len(call.Args)}
+	if len(call.Args) != 3 && len(call.Args) != 5 {
+		return nil, ErrInvalidArguments{Expected: "3 or 5", Actual: len(call.Args)}
 	}
 
 	name, ok := call.Args[0].(*Identifier)
 	if !ok {
-		return nil, ErrArgumentType{expected: "identifier", actual: call.Args[0].Type()}
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
+	}
+
+	if specialForms[name.Name] {
+		return nil, ErrReserved{Name: name.Name}
+	}
+
+	if s.consts[name.Name] {
+		return nil, ErrConstant{Name: name.Name}
 	}
 
 	argList, ok := call.Args[1].(*List)
 	if !ok {
-		return nil, ErrArgumentType{expected: "list", actual: call.Args[1].Type()}
+		return nil, ErrArgumentType{Arg: 2, Expected: "list", Actual: call.Args[1].Type()}
+	}
+
+	args, params, err := castParams(argList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args {
+		s.exec.redefine(arg)
+	}
+	resolveFunc(call, argList.Values, 2)
+	fn := &Closure{Name: name.Name, ArgNames: args, Params: params, Body: call.Args[2], Scope: s}
+	if len(call.Args) == 5 {
+		if fn.Pre, fn.Post, err = castContract(call.Args[3], call.Args[4]); err != nil {
+			return nil, err
+		}
+	}
+
+	s.SetVar(name.Name, fn)
+	return Null{}, nil
+}
+
+// let binds the names in a fresh scope and evaluates the body in it. All
+// values are evaluated in the enclosing scope before any name is bound, so
+// the bindings don't see each other:
+//
+//	(let ((x 1) (y (plus x 1))) (times x y))
+//
+// here y sees the x of the enclosing scope, not the one bound by let.
+func (s *Scope) let(call *Call) (Expression, error) {
+	bindings, err := castBindings(call)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := NewScope("let", s, s.PrintNulls)
+	for _, b := range bindings {
+		if err = s.bind(scope, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return scope.evalBody(call.Args[1:])
+}
+
+// letSeq is let*, it binds the names one by one, each value is evaluated
+// in the scope that already has the previous bindings.
+func (s *Scope) letSeq(call *Call) (Expression, error) {
+	bindings, err := castBindings(call)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := NewScope("let", s, s.PrintNulls)
+	for _, b := range bindings {
+		if err = scope.bind(scope, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return scope.evalBody(call.Args[1:])
+}
+
+// letrec binds the lambdas before evaluating any other value, so that the
+// local functions can call each other regardless of the order they are
+// listed in. The rest of values are bound as in let*.
+func (s *Scope) letrec(call *Call) (Expression, error) {
+	bindings, err := castBindings(call)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := NewScope("let", s, s.PrintNulls)
+	for _, b := range bindings {
+		if lambda, ok := b.value.(*Call); ok && lambda.Name == "lambda" {
+			if err = scope.bind(scope, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, b := range bindings {
+		if lambda, ok := b.value.(*Call); ok && lambda.Name == "lambda" {
+			continue
+		}
+		if err = scope.bind(scope, b); err != nil {
+			return nil, err
+		}
+	}
+
+	return scope.evalBody(call.Args[1:])
+}
+
+type binding struct {
+	name    string
+	pattern Expression // nil, unless the value is destructured
+	value   Expression
+}
+
+func castBindings(call *Call) ([]binding, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	list, ok := call.Args[0].(*List)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "list", Actual: call.Args[0].Type()}
 	}
 
-	args := make([]string, len(argList.Values))
-	for idx, expr := range argList.Values {
-		str, ok := expr.(*Identifier)
-		if !ok {
-			return nil, fmt.Errorf("argument %d: %w", idx, ErrArgumentType{expected: "identifier", actual: expr.Type()})
+	result := make([]binding, len(list.Values))
+	for idx, expr := range list.Values {
+		pair, ok := expr.(*List)
+		if !ok || len(pair.Values) != 2 {
+			return nil, fmt.Errorf("binding %d: %w", idx, ErrArgumentType{Expected: "binding", Actual: expr.Type()})
 		}
-		args[idx] = str.Name
+
+		switch name := pair.Values[0].(type) {
+		case *Identifier:
+			result[idx] = binding{name: name.Name, value: pair.Values[1]}
+		case *Call:
+			result[idx] = binding{name: sourceString(name), pattern: name, value: pair.Values[1]}
+		default:
+			return nil, fmt.Errorf("binding %d: %w", idx, ErrArgumentType{Expected: "identifier or pattern", Actual: name.Type()})
+		}
+	}
+
+	return result, nil
+}
+
+// evalBody evaluates the expressions one by one and gives back the last
+// one as a tail call, as its value is the value of the body. It stops
+// after the expression, which caused a break, continue or return.
+func (s *Scope) evalBody(exprs []Expression) (Expression, error) {
+	if len(exprs) == 0 {
+		return Null{}, nil
+	}
+
+	last := len(exprs) - 1
+	res, err := s.evalStatements(exprs[:last])
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := res.(*tailCall); ok || s.interrupted() {
+		return res, nil
 	}
 
-	s.SetFunc(name.Name, args, call.Args[2])
+	return &tailCall{expr: exprs[last], scope: s}, nil
+}
+
+// evalStatements evaluates the expressions one by one and stops after the
+// one, which caused a break, continue or return. It returns the tail call
+// with the returned value, if the function returns, and null otherwise.
+func (s *Scope) evalStatements(exprs []Expression) (Expression, error) {
+	for idx, expr := range exprs {
+		res, err := s.evalTail(expr, true)
+		if err != nil {
+			return nil, fmt.Errorf("evaluate expression %d: %w", idx, err)
+		}
+		if _, ok := res.(*tailCall); ok {
+			return res, nil
+		}
+		if s.interrupted() {
+			break
+		}
+	}
 	return Null{}, nil
 }
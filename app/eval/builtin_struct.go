@@ -0,0 +1,135 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defstruct declares the record type with the fields and defines its
+// functions:
+//
+//	(defstruct point x y)
+//	(setq p (make-point 1 2)) // the constructor
+//	(point-x p)               // the accessor of each field, 1
+//	(ispoint p)               // the type predicate, true
+//	(point-with p 'x 5)       // the copy with the fields changed, point{x: 5, y: 2}
+func (s *Scope) defstruct(call *Call) (Expression, error) {
+	if len(call.Args) < 1 {
+		return nil, ErrInvalidArguments{Expected: "at least 1", Actual: len(call.Args)}
+	}
+
+	name, ok := call.Args[0].(*Identifier)
+	if !ok {
+		return nil, ErrArgumentType{Arg: 1, Expected: "identifier", Actual: call.Args[0].Type()}
+	}
+
+	if isBuiltinType(name.Name) {
+		return nil, fmt.Errorf("%s is a builtin type and can't be redefined", name.Name)
+	}
+
+	def := &StructDef{Name: name.Name}
+	for idx, expr := range call.Args[1:] {
+		field, ok := expr.(*Identifier)
+		if !ok {
+			return nil, fmt.Errorf("field %d: %w", idx, ErrArgumentType{Expected: "identifier", Actual: expr.Type()})
+		}
+		if def.field(field.Name) >= 0 {
+			return nil, fmt.Errorf("field %s is declared twice", field.Name)
+		}
+		def.Fields = append(def.Fields, field.Name)
+	}
+
+	fns := []*Native{
+		{Name: "make-" + def.Name, Arity: len(def.Fields), Fn: def.construct},
+		{Name: "is" + def.Name, Arity: 1, Fn: def.is},
+		{Name: def.Name + "-with", Arity: -1, Fn: def.with},
+	}
+	for idx, field := range def.Fields {
+		fns = append(fns, &Native{Name: def.Name + "-" + field, Arity: 1, Fn: def.accessor(idx)})
+	}
+
+	for _, fn := range fns {
+		if specialForms[fn.Name] {
+			return nil, ErrReserved{Name: fn.Name}
+		}
+	}
+	for _, fn := range fns {
+		s.SetVar(fn.Name, fn)
+	}
+
+	return Null{}, nil
+}
+
+func (d *StructDef) construct(s *Scope, args []Expression) (Expression, error) {
+	if err := s.exec.alloc(len(args)); err != nil {
+		return nil, err
+	}
+	return &Struct{Def: d, Values: append([]Expression(nil), args...)}, nil
+}
+
+func (d *StructDef) is(_ *Scope, args []Expression) (Expression, error) {
+	st, ok := args[0].(*Struct)
+	return &Boolean{Value: ok && st.Def == d}, nil
+}
+
+func (d *StructDef) accessor(idx int) func(*Scope, []Expression) (Expression, error) {
+	return func(_ *Scope, args []Expression) (Expression, error) {
+		st, err := d.cast(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return st.Values[idx], nil
+	}
+}
+
+// with returns the copy of the record with the new values of the fields,
+// which go in pairs after it: the symbol of the field name and the value.
+func (d *StructDef) with(s *Scope, args []Expression) (Expression, error) {
+	if len(args)%2 != 1 {
+		return nil, ErrInvalidArguments{Expected: "record and pairs of", Actual: len(args)}
+	}
+
+	st, err := d.cast(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.exec.alloc(len(st.Values)); err != nil {
+		return nil, err
+	}
+
+	res := &Struct{Def: d, Values: append([]Expression(nil), st.Values...)}
+	for idx := 1; idx < len(args); idx += 2 {
+		field, ok := args[idx].(*Symbol)
+		if !ok {
+			return nil, ErrArgumentType{Arg: idx + 1, Expected: "symbol", Actual: args[idx].Type()}
+		}
+		pos := d.field(field.Name)
+		if pos < 0 {
+			return nil, fmt.Errorf("%s of %s with fields %s: %w",
+				field.Name, d.Name, strings.Join(d.Fields, ", "), ErrNoField)
+		}
+		res.Values[pos] = args[idx+1]
+	}
+
+	return res, nil
+}
+
+// cast returns the record of the type.
+func (d *StructDef) cast(expr Expression) (*Struct, error) {
+	st, ok := expr.(*Struct)
+	if !ok || st.Def != d {
+		return nil, ErrArgumentType{Expected: d.Name, Actual: expr.Type()}
+	}
+	return st, nil
+}
+
+// isBuiltinType reports whether the name is the type of builtin values,
+// which the records must not be taken for.
+func isBuiltinType(name string) bool {
+	switch name {
+	case "number", "boolean", "null", "list", "function", "string", "symbol", "map", "error":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Error represents the error as an F value: the one thrown by the program
+// or the one of the evaluation, caught by try. It is a Go error as well,
+// so that throw passes it up to the nearest try.
+type Error struct {
+	Kind    string // e.g. "zero-division", "throw" for thrown values
+	Message string
+	Data    Expression // the thrown value, or null
+
+	cause error // the error of the evaluation, if the error is caught one
+}
+
+// Type returns the type of the error.
+func (e *Error) Type() string { return "error" }
+
+// String returns the string representation of the error.
+func (e *Error) String() string { return e.Error() }
+
+// FString returns the F language representation of the error.
+func (e *Error) FString() string {
+	if _, ok := e.Data.(Null); ok || e.Data == nil {
+		return fmt.Sprintf("(error '%s %q)", e.Kind, e.Message)
+	}
+	return fmt.Sprintf("(error '%s %q %s)", e.Kind, e.Message, e.Data.FString())
+}
+
+// Equal returns true if both errors have the same kind and message and
+// equal data.
+func (e *Error) Equal(b Expression) bool {
+	e2, ok := b.(*Error)
+	return ok && e.Kind == e2.Kind && e.Message == e2.Message && e.data().Equal(e2.data())
+}
+
+// Error returns string representation of the error.
+func (e *Error) Error() string { return fmt.Sprintf("%s: %s", e.Kind, e.Message) }
+
+// Unwrap returns the error of the evaluation, which the error was made of.
+func (e *Error) Unwrap() error { return e.cause }
+
+func (e *Error) data() Expression {
+	if e.Data == nil {
+		return Null{}
+	}
+	return e.Data
+}
+
+// errorKinds are the kinds of the errors of the evaluation.
+var errorKinds = []struct {
+	kind  string
+	match func(error) bool
+}{
+	{"zero-division", errIs(ErrZeroDivision)},
+	{"zero-step", errIs(ErrZeroStep)},
+	{"invalid-context", errIs(ErrInvalidContext)},
+	{"domain", errIs(ErrDomain)},
+	{"overflow", errIs(ErrOverflow)},
+	{"empty-list", errIs(ErrEmptyList)},
+	{"out-of-range", errIs(ErrOutOfRange)},
+	{"no-field", errIs(ErrNoField)},
+	{"no-match", errIs(ErrNoMatch)},
+	{"invalid-pattern", errIs(ErrInvalidPattern)},
+	{"arity", errAs(func() interface{} { return &ErrInvalidArguments{} })},
+	{"argument-type", errAs(func() interface{} { return &ErrArgumentType{} })},
+	{"undefined", errAs(func() interface{} { return &ErrUndefined{} })},
+	{"not-function", errAs(func() interface{} { return &ErrNotFunction{} })},
+	{"reserved", errAs(func() interface{} { return &ErrReserved{} })},
+	{"constant", errAs(func() interface{} { return &ErrConstant{} })},
+	{"defined", errAs(func() interface{} { return &ErrDefined{} })},
+	{"invalid-expression", errAs(func() interface{} { return &ErrInvalidExpression{} })},
+	{"invalid-clause", errAs(func() interface{} { return &ErrInvalidClause{} })},
+	{"contract", errAs(func() interface{} { return &ErrContract{} })},
+}
+
+// toError returns the error of the evaluation as an F value, or false, if
+// the error must not be caught: the limits of the evaluation and the
+// cancellation of its context stop the program whatever it does.
+func toError(err error) (*Error, bool) {
+	var limit ErrLimitExceeded
+	if errors.As(err, &limit) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil, false
+	}
+
+	// the stack of the calls isn't kept, so that the error, thrown again,
+	// gets the one of its new place
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		err = evalErr.Err
+	}
+
+	var thrown *Error
+	if errors.As(err, &thrown) {
+		return thrown, true
+	}
+
+	// the message of the innermost error, without the calls it was
+	// wrapped with
+	msg := err
+	for next := errors.Unwrap(msg); next != nil; next = errors.Unwrap(msg) {
+		msg = next
+	}
+
+	res := &Error{Kind: ErrorCode(err), Message: msg.Error(), Data: Null{}, cause: err}
+
+	var undefined ErrUndefined
+	if errors.As(err, &undefined) {
+		res.Data = &Symbol{Name: undefined.Name}
+	}
+
+	return res, true
+}
+
+// ErrorCode returns the stable code of the error of the evaluation, which
+// is the kind of the error, as F programs see it, e.g. "zero-division", or
+// "error" for any other error. The errors, which can't be caught, have the
+// codes as well: "limit" for the exceeded limits, "canceled" and "timeout"
+// for the context of the evaluation.
+func ErrorCode(err error) string {
+	var limit ErrLimitExceeded
+	switch {
+	case errors.As(err, &limit):
+		return "limit"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+
+	var thrown *Error
+	if errors.As(err, &thrown) {
+		return thrown.Kind
+	}
+
+	for _, k := range errorKinds {
+		if k.match(err) {
+			return k.kind
+		}
+	}
+	return "error"
+}
+
+func errIs(target error) func(error) bool {
+	return func(err error) bool { return errors.Is(err, target) }
+}
+
+// errAs returns the function, which tells whether the error has the one
+// of the type in its chain, target returns a new pointer to the type.
+func errAs(target func() interface{}) func(error) bool {
+	return func(err error) bool { return errors.As(err, target()) }
+}
@@ -3,35 +3,141 @@ package eval
 import (
 	"fmt"
 	"errors"
+	"strings"
 )
 
 // ErrInvalidArguments is returned when the number of arguments passed to a
 // function is invalid.
 type ErrInvalidArguments struct {
-	expected string
-	actual   int
+	Function string // the name of the function, empty if it is unknown
+	Expected string // e.g. "2", "at least 1"
+	Actual   int
 }
 
 // Error returns string representation of the error.
 func (e ErrInvalidArguments) Error() string {
-	return fmt.Sprintf("expected %s arguments, got %d", e.expected, e.actual)
+	if e.Function == "" {
+		return fmt.Sprintf("expected %s arguments, got %d", e.Expected, e.Actual)
+	}
+	return fmt.Sprintf("%s expected %s arguments, got %d", e.Function, e.Expected, e.Actual)
 }
 
 // ErrArgumentType is returned when the type of argument passed to a function
 // is invalid.
 type ErrArgumentType struct {
-	expected string
-	actual   string
+	Function string // the name of the function, empty if it is unknown
+	Arg      int    // the position of the argument, starting with 1, 0 if it is unknown
+	Expected string
+	Actual   string
 }
 
 // Error returns string representation of the error.
 func (e ErrArgumentType) Error() string {
-	return fmt.Sprintf("expected argument of type %s, got %s", e.expected, e.actual)
+	msg := fmt.Sprintf("expected argument of type %s, got %s", e.Expected, e.Actual)
+	switch {
+	case e.Function != "" && e.Arg > 0:
+		return fmt.Sprintf("%s argument %d: %s", e.Function, e.Arg, msg)
+	case e.Function != "":
+		return fmt.Sprintf("%s: %s", e.Function, msg)
+	case e.Arg > 0:
+		return fmt.Sprintf("argument %d: %s", e.Arg, msg)
+	}
+	return msg
+}
+
+// attribute returns the error of the call of the builtin or native function
+// with the arguments, which sets the function and the argument of the call
+// to the errors of the arguments and names, as the helpers, which fail with
+// them, don't know the call they fail in. The errors of the nested calls
+// have their functions set already.
+func attribute(name string, args []Expression, err error) error {
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		return err
+	}
+	return callError{err: err, function: name, args: args}
+}
+
+// callError is the error of the call of the function with the arguments.
+type callError struct {
+	err      error
+	function string
+	args     []Expression // unevaluated, nil for the native functions
+}
+
+// Error returns string representation of the error.
+func (e callError) Error() string { return e.err.Error() }
+
+// Unwrap returns the error of the call.
+func (e callError) Unwrap() error { return e.err }
+
+// As finds the error of the call as errors.As does and sets the function
+// and the argument of the call to it, if it is one of the errors, which
+// have them.
+func (e callError) As(target interface{}) bool {
+	if !errors.As(e.err, target) {
+		return false
+	}
+	if target, ok := target.(attributable); ok {
+		target.attribute(e.function, e.args)
+	}
+	return true
+}
+
+// attributable is the error, which has the function and the argument of
+// the call it failed in, they are set unless they are known already.
+type attributable interface {
+	attribute(function string, args []Expression)
+}
+
+func (e *ErrInvalidArguments) attribute(function string, _ []Expression) {
+	if e.Function == "" {
+		e.Function = function
+	}
+}
+
+func (e *ErrArgumentType) attribute(function string, _ []Expression) {
+	if e.Function == "" {
+		e.Function = function
+	}
+}
+
+func (e *ErrUndefined) attribute(function string, args []Expression) {
+	e.Function, e.Arg = attributeName(e.Function, e.Arg, e.Name, function, args)
+}
+
+func (e *ErrReserved) attribute(function string, args []Expression) {
+	e.Function, e.Arg = attributeName(e.Function, e.Arg, e.Name, function, args)
+}
+
+func (e *ErrConstant) attribute(function string, args []Expression) {
+	e.Function, e.Arg = attributeName(e.Function, e.Arg, e.Name, function, args)
+}
+
+func (e *ErrDefined) attribute(function string, args []Expression) {
+	e.Function, e.Arg = attributeName(e.Function, e.Arg, e.Name, function, args)
+}
+
+// attributeName returns the function and the argument of the error of the
+// name, the argument is the one, which is the name itself, 0 if the name
+// is deeper in the arguments.
+func attributeName(curFunction string, curArg int, name, function string, args []Expression) (string, int) {
+	if curFunction != "" {
+		return curFunction, curArg
+	}
+	for idx, arg := range args {
+		if ident, ok := arg.(*Identifier); ok && ident.Name == name {
+			return function, idx + 1
+		}
+	}
+	return function, 0
 }
 
 // ErrUndefined is returned when the name of a variable is undefined.
 type ErrUndefined struct {
-	Name string
+	Name     string
+	Function string // the name of the function, which failed, empty if it is unknown
+	Arg      int    // the position of the name in its arguments, 0 if it is unknown
 }
 
 // Error returns string representation of the error.
@@ -47,6 +153,42 @@ func (e ErrNotFunction) Error() string {
 	return fmt.Sprintf("%s is not a function", e.Name)
 }
 
+// ErrReserved is returned on attempt to define a variable with the name of
+// a special form, or to assign a builtin function.
+type ErrReserved struct {
+	Name     string
+	Function string // the name of the function, which failed, empty if it is unknown
+	Arg      int    // the position of the name in its arguments, 0 if it is unknown
+}
+
+// Error returns string representation of the error.
+func (e ErrReserved) Error() string {
+	return fmt.Sprintf("%s is a builtin and can't be redefined", e.Name)
+}
+
+// ErrConstant is returned on attempt to assign the variable bound by const.
+type ErrConstant struct {
+	Name     string
+	Function string // the name of the function, which failed, empty if it is unknown
+	Arg      int    // the position of the name in its arguments, 0 if it is unknown
+}
+
+// Error returns string representation of the error.
+func (e ErrConstant) Error() string {
+	return fmt.Sprintf("%s is a constant and can't be assigned", e.Name)
+}
+
+// ErrDefined is returned on attempt to define the variable, which is bound
+// in the scope already.
+type ErrDefined struct {
+	Name     string
+	Function string // the name of the function, which failed, empty if it is unknown
+	Arg      int    // the position of the name in its arguments, 0 if it is unknown
+}
+
+// Error returns string representation of the error.
+func (e ErrDefined) Error() string { return fmt.Sprintf("%s is already defined", e.Name) }
+
 // ErrInvalidExpression is returned when the expression is invalid.
 type ErrInvalidExpression struct {
 	Expr Expression
@@ -57,7 +199,65 @@ func (e ErrInvalidExpression) Error() string {
 	return fmt.Sprintf("invalid expression: %s", e.Expr)
 }
 
+// ErrInvalidClause is returned when a clause of a multi-way form, such as
+// cond or case, is malformed.
+type ErrInvalidClause struct {
+	Form   string
+	Index  int
+	Reason string
+}
+
+// Error returns string representation of the error.
+func (e ErrInvalidClause) Error() string {
+	return fmt.Sprintf("%s clause %d: %s", e.Form, e.Index, e.Reason)
+}
+
+// The errors below carry neither the function nor the argument, the call,
+// which failed with them, is the one of the EvalError.
 var (
 	ErrZeroDivision   = errors.New("zero division")
+	ErrZeroStep       = errors.New("zero loop step")
 	ErrInvalidContext = errors.New("statement is illegal in this context")
+	ErrDomain         = errors.New("argument is out of the function domain")
+	ErrOverflow       = errors.New("result is too large for a float")
+	ErrEmptyList      = errors.New("empty list")
+	ErrOutOfRange     = errors.New("index out of range")
+	ErrNoField        = errors.New("no such field")
+	ErrNoMatch        = errors.New("no pattern matches the value")
+	ErrInvalidPattern = errors.New("invalid pattern")
 )
+
+// ErrLimitExceeded is returned when the evaluation exceeds one of its
+// limits, Limit is either "depth", "steps" or "values".
+type ErrLimitExceeded struct {
+	Limit string
+	Max   int
+}
+
+// Error returns string representation of the error.
+func (e ErrLimitExceeded) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// ErrContract is returned when the condition of the contract of a function
+// or an assertion is false. Kind is either "precondition", "postcondition"
+// or "assertion", Values are the arguments of the function and its result,
+// e.g. "x = 1", or the message of the assertion.
+type ErrContract struct {
+	Kind      string
+	Function  string
+	Condition string
+	Values    []string
+}
+
+// Error returns string representation of the error.
+func (e ErrContract) Error() string {
+	msg := fmt.Sprintf("%s %s failed", e.Kind, e.Condition)
+	if e.Function != "" {
+		msg = fmt.Sprintf("%s %s of %s failed", e.Kind, e.Condition, e.Function)
+	}
+	if len(e.Values) > 0 {
+		msg += ": " + strings.Join(e.Values, ", ")
+	}
+	return msg
+}
@@ -7,53 +7,60 @@ import (
 	"errors"
 )
 
-// Function describes a defined function.
-type Function struct {
-	ArgNames []string
-	Body     Expression
-}
-
 // Scope is an evaluator for expressions.
 type Scope struct {
 	Parent     *Scope
 	Vars       map[string]Expression
-	Funcs      map[string]Function
 	Context    string
 	Return     Expression
 	PrintNulls bool
+
+	consts map[string]bool // the variables bound by const
+	index  map[string]int  // the positions of the parameters of the function call
+	slots  []Expression    // the values of the parameters
+	exec   *execution
 }
 
 // NewScope creates a new evaluator.
 func NewScope(ctx string, parent *Scope, printNulls bool) *Scope {
 	result := &Scope{
 		Parent:     parent,
-		Vars:       make(map[string]Expression),
-		Funcs:      make(map[string]Function),
 		PrintNulls: printNulls,
 		Context:    ctx,
+		exec:       &execution{},
 	}
 
 	if parent != nil {
-		for name, fn := range parent.Funcs {
-			result.Funcs[name] = fn
-		}
+		result.exec = parent.exec
 	}
 
-	if ctx == "prog" && parent != nil && parent.Context == "func" {
-		for name, v := range parent.Vars {
-			result.Vars[name] = v
+	return result
+}
+
+// transparent reports whether the scope lets the variables of its parent
+// be seen through it.
+func (s *Scope) transparent() bool {
+	return s.Context == "let" || s.Context == "loop"
+}
+
+// interrupted reports whether a break or continue is pending for
+// the scope, so the rest of the block it evaluates must be skipped.
+func (s *Scope) interrupted() bool {
+	for sc := s; sc != nil; sc = sc.Parent {
+		if sc.Return != nil {
+			return true
+		}
+		if sc.Context == "func" {
+			break
 		}
 	}
-
-	return result
+	return false
 }
 
 // GetVar returns the value of the expression with the given name.
 func (s *Scope) GetVar(name string, searchInParentScopes bool) (Expression, error) {
-	if s.Vars != nil {
-		if v, ok := s.Vars[name]; ok {
-			return v, nil
-		}
+	if v, ok := s.local(name); ok {
+		return v, nil
 	}
 
 	if searchInParentScopes && s.Parent != nil {
@@ -65,167 +72,511 @@ func (s *Scope) GetVar(name string, searchInParentScopes bool) (Expression, erro
 
 // SetVar sets the value of the expression with the given name.
 func (s *Scope) SetVar(name string, val Expression) {
+	if slot := s.slot(name); slot >= 0 {
+		s.slots[slot] = val
+		return
+	}
+	s.exec.redefine(name)
 	if s.Vars == nil {
 		s.Vars = make(map[string]Expression)
 	}
 	s.Vars[name] = val
 }
 
-// SetFunc sets the function in the scope.
-func (s *Scope) SetFunc(name string, args []string, body Expression) {
-	s.Funcs[name] = Function{ArgNames: args, Body: body}
+// local returns the value of the variable bound in the scope itself.
+func (s *Scope) local(name string) (Expression, bool) {
+	if slot := s.slot(name); slot >= 0 {
+		return s.slots[slot], true
+	}
+	v, ok := s.Vars[name]
+	return v, ok
+}
+
+// slot returns the position of the parameter of the function call, or -1,
+// if the scope has no such one.
+func (s *Scope) slot(name string) int {
+	if idx, ok := s.index[name]; ok {
+		return idx
+	}
+	return -1
+}
+
+// assign sets the value of the variable for setq. Let and loop scopes own
+// only the variables they bind themselves, so unless the variable is bound
+// by one of them, it is set in the first scope of any other kind. Constants
+// can't be assigned, and in the strict mode the variable must be bound in
+// that scope already.
+func (s *Scope) assign(name string, val Expression) error {
+	sc := s
+	for sc.transparent() && sc.Parent != nil {
+		if _, ok := sc.local(name); ok {
+			break
+		}
+		sc = sc.Parent
+	}
+
+	if sc.consts[name] {
+		return ErrConstant{Name: name}
+	}
+	if _, ok := sc.local(name); !ok && s.exec.strict {
+		return fmt.Errorf("strict mode: %w", ErrUndefined{Name: name})
+	}
+
+	sc.SetVar(name, val)
+	return nil
 }
 
-// GetFunc returns the function with the given name.
-func (s *Scope) GetFunc(name string) (Function, error) {
-	if s.Funcs != nil {
-		if f, ok := s.Funcs[name]; ok {
-			return f, nil
+// update sets the value of the nearest binding of the variable for set!,
+// looking it up the same way as GetVar does, so that a function updates
+// the variables of the scope it was defined in.
+func (s *Scope) update(name string, val Expression) error {
+	if specialForms[name] {
+		return ErrReserved{Name: name}
+	}
+
+	for sc := s; sc != nil; sc = sc.Parent {
+		if _, ok := sc.local(name); !ok {
+			continue
 		}
+		if sc.consts[name] {
+			return ErrConstant{Name: name}
+		}
+		sc.SetVar(name, val)
+		return nil
+	}
+
+	if _, ok := builtinMethods[name]; ok {
+		return ErrReserved{Name: name}
+	}
+	return ErrUndefined{Name: name}
+}
+
+// declare binds the name in the scope for define and const, the name must
+// not be bound in the scope yet.
+func (s *Scope) declare(name string, val Expression, constant bool) error {
+	if specialForms[name] {
+		return ErrReserved{Name: name}
+	}
+	if _, ok := s.local(name); ok {
+		return ErrDefined{Name: name}
 	}
-	if s.Parent != nil {
-		return s.Parent.GetFunc(name)
+
+	if constant {
+		s.SetConst(name, val)
+		return nil
+	}
+	s.SetVar(name, val)
+	return nil
+}
+
+// SetConst binds the name to the value in the scope as const does, so that
+// neither setq nor set! can assign it, though a nested scope may bind the
+// name anew.
+func (s *Scope) SetConst(name string, val Expression) {
+	s.SetVar(name, val)
+	if s.consts == nil {
+		s.consts = map[string]bool{}
 	}
-	return Function{}, ErrUndefined{Name: name}
+	s.consts[name] = true
+}
+
+// SetFunc defines the function in the scope, the function captures the
+// scope as its environment. Functions share the namespace with other
+// variables.
+func (s *Scope) SetFunc(name string, args []string, body Expression) {
+	s.SetVar(name, &Closure{Name: name, ArgNames: args, Body: body, Scope: s})
 }
 
 // SetBreak sets the return value of the evaluator.
 func (s *Scope) SetBreak() error {
-	for s.Parent != nil && s.Context != "while" && s.Context != "func" {
-		s = s.Parent
+	loop, err := s.loop()
+	if err != nil {
+		return err
 	}
-	if s.Context != "while" || s.Parent == nil {
-		return ErrInvalidContext
+	loop.Return = brk{}
+	return nil
+}
+
+// SetContinue makes the loop skip the rest of its body and continue with
+// the next iteration.
+func (s *Scope) SetContinue() error {
+	loop, err := s.loop()
+	if err != nil {
+		return err
 	}
-	s.Return = brk{}
+	loop.Return = cont{}
 	return nil
 }
 
-// SetReturn sets the return value of the evaluator.
-func (s *Scope) SetReturn(val Expression) error {
-	for s.Parent != nil && s.Context != "func" {
+// loop returns the scope of the innermost loop in the current function.
+func (s *Scope) loop() (*Scope, error) {
+	for s.Parent != nil && s.Context != "loop" && s.Context != "func" {
 		s = s.Parent
 	}
-	if s.Context != "func" || s.Parent == nil {
-		return ErrInvalidContext
+	if s.Context != "loop" || s.Parent == nil {
+		return nil, ErrInvalidContext
+	}
+	return s, nil
+}
+
+// funcScope returns the scope of the innermost function call, nil at the
+// top level.
+func (s *Scope) funcScope() *Scope {
+	for ; s != nil; s = s.Parent {
+		if s.Context == "func" {
+			return s
+		}
 	}
-	s.Return = val
 	return nil
 }
 
 // Eval evaluates the given expression.
 func (s *Scope) Eval(expr Expression) (Expression, error) {
-	switch expr := expr.(type) {
-	case *Call:
-		result, err := s.call(expr)
+	return s.evalTail(expr, false)
+}
+
+// evalTail evaluates the expression in a loop: the forms give back the
+// expressions in their tail positions as tail calls, and the calls of
+// closures go on with the body in the scope of the call, so the Go stack
+// doesn't grow with them. If untilReturn is set, the loop stops at the
+// return of the current function and gives its tail call back, so that the
+// enclosing blocks are left before the returned value is evaluated.
+func (s *Scope) evalTail(expr Expression, untilReturn bool) (Expression, error) {
+	var fn *Scope
+	if untilReturn {
+		fn = s.funcScope()
+	}
+
+	// the place of the call, which evaluates the expression, is restored
+	// for the rest of it, once the nested calls are done
+	defer func(exec *execution, pos Position) { exec.pos = pos }(s.exec, s.exec.pos)
+
+	entered := false
+	for {
+		if err := s.exec.step(); err != nil {
+			return nil, err
+		}
+
+		call, ok := expr.(*Call)
+		if !ok {
+			return s.evalValue(expr)
+		}
+
+		if err := s.exec.done(); err != nil {
+			return nil, s.exec.fail(call, err)
+		}
+
+		s.exec.pos = call.Pos
+		result, err := s.call(call)
 		if err != nil {
-			return nil, fmt.Errorf("call %q: %w", expr.Name, err)
+			return nil, s.exec.fail(call, err)
 		}
-		return result, nil
-	case *Number:
+
+		tail, ok := result.(*tailCall)
+		if !ok {
+			// null is no value to allocate, and it is what the calls for
+			// the side effects return, e.g. setq, which must not fail once
+			// the variable is set
+			if _, null := result.(Null); null {
+				return result, nil
+			}
+			if err = s.exec.alloc(1); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+
+		// the loop is a single call on the stack, however many tail
+		// calls it goes through
+		switch {
+		case tail.frame != nil && !entered:
+			if err = s.exec.enter(tail.frame); err != nil {
+				return nil, s.exec.fail(call, err)
+			}
+			defer s.exec.leave()
+			entered = true
+		case tail.frame != nil:
+			s.exec.replace(tail.frame)
+		}
+
+		if untilReturn && tail.ret && tail.scope.funcScope() == fn {
+			return tail, nil
+		}
+
+		s, expr = tail.scope, tail.expr
+	}
+}
+
+func (s *Scope) evalValue(expr Expression) (Expression, error) {
+	switch expr := expr.(type) {
+	case *Number, *Integer, *Rational:
 		return expr, nil
+	case *Local:
+		return s.lookupLocal(expr), nil
 	case *Identifier:
-		v, err := s.GetVar(expr.Name, false)
-		if errors.Is(err, &ErrUndefined{}) {
-			fn, err := s.GetFunc(expr.Name)
-			if err != nil {
-				return nil, err
+		v, err := s.GetVar(expr.Name, true)
+		var undefined ErrUndefined
+		if errors.As(err, &undefined) {
+			if _, ok := builtinMethods[expr.Name]; ok {
+				return &Builtin{Name: expr.Name}, nil
+			}
+			if c, ok := builtinConstants[expr.Name]; ok {
+				return c, nil
 			}
-			return makeLambdaCall(fn), nil
 		}
-		return v, nil
-	case *List:
+		return v, err
+	case *List, *Map, *Struct, *Variant, *Error:
 		return expr, nil
-	case *Boolean:
+	case *Boolean, *String, *Symbol:
 		return expr, nil
 	case Null:
 		return Null{}, nil
+	case *Closure, *Builtin, *Native:
+		return expr, nil
 	}
 	return nil, ErrInvalidExpression{Expr: expr}
 }
 
-func makeLambdaCall(fn Function) Expression {
-	argsList := &List{Values: make([]Expression, len(fn.ArgNames))}
+func (s *Scope) call(call *Call) (Expression, error) {
+	log.Printf("[DEBUG] call %s", call)
+	builtin, ok := builtinMethods[call.Name]
+	if ok && !s.exec.shadow[call.Name] {
+		return s.callBuiltin(builtin, call)
+	}
 
-	for _, argname := range fn.ArgNames {
-		argsList.Values = append(argsList.Values, &Identifier{Name: argname})
+	fn, err := s.GetVar(call.Name, true)
+	if err != nil {
+		if ok {
+			// the builtin is redefined, though not in this scope
+			return s.callBuiltin(builtin, call)
+		}
+		return nil, err
 	}
 
-	return &Call{
-		Name: "lambda",
-		Args: []Expression{argsList, fn.Body},
+	if !isCallable(fn) {
+		return nil, ErrNotFunction{Name: call.Name}
+	}
+
+	args, err := s.evalArgs(call.Args)
+	if err != nil {
+		return nil, attribute(call.Name, call.Args, err)
 	}
+
+	return s.applyTail(fn, args)
 }
 
-func (s *Scope) call(call *Call) (Expression, error) {
-	log.Printf("[DEBUG] call %s", call)
-	if expr, ok := builtinMethods[call.Name]; ok {
-		return expr(s, call)
+func (s *Scope) callBuiltin(builtin func(*Scope, *Call) (Expression, error), call *Call) (Expression, error) {
+	res, err := builtin(s, call)
+	if err != nil {
+		return nil, attribute(call.Name, call.Args, err)
 	}
+	return res, nil
+}
 
-	fn, err := s.GetFunc(call.Name)
+// Apply calls the function with the already evaluated arguments.
+func (s *Scope) Apply(fn Expression, args []Expression) (Expression, error) {
+	result, err := s.applyTail(fn, args)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(call.Args) != len(fn.ArgNames) {
+	tail, ok := result.(*tailCall)
+	if !ok {
+		return result, nil
+	}
+
+	if tail.frame != nil {
+		if err = s.exec.enter(tail.frame); err != nil {
+			return nil, err
+		}
+		defer s.exec.leave()
+	}
+
+	if result, err = tail.scope.Eval(tail.expr); err != nil {
+		if closure, ok := fn.(*Closure); ok {
+			return nil, fmt.Errorf("evaluate function %s body: %w", closure.name(), err)
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// applyTail calls the function, but leaves the body of a closure to the
+// caller as a tail call.
+func (s *Scope) applyTail(fn Expression, args []Expression) (Expression, error) {
+	switch fn := fn.(type) {
+	case *Closure:
+		scope, err := s.enter(fn, args)
+		if err != nil {
+			return nil, err
+		}
+		frame := &Frame{Function: fn.name(), Args: args, Pos: s.exec.pos}
+		if len(fn.Post) > 0 && !s.exec.noContracts {
+			return s.applyChecked(fn, scope, frame)
+		}
+		return &tailCall{expr: fn.Body, scope: scope, frame: frame}, nil
+	case *Builtin:
+		return s.applyBuiltin(fn, args)
+	case *Native:
+		if fn.Arity >= 0 && len(args) != fn.Arity {
+			return nil, ErrInvalidArguments{Function: fn.Name, Expected: strconv.Itoa(fn.Arity), Actual: len(args)}
+		}
+		res, err := fn.Fn(s, args)
+		if err != nil {
+			return nil, attribute(fn.Name, nil, err)
+		}
+		return res, nil
+	}
+	return nil, ErrArgumentType{Expected: "function", Actual: fn.Type()}
+}
+
+// enter returns the scope of the function call with the arguments bound.
+func (s *Scope) enter(fn *Closure, args []Expression) (*Scope, error) {
+	if len(args) != len(fn.ArgNames) {
 		return nil, ErrInvalidArguments{
-			expected: strconv.Itoa(len(fn.ArgNames)),
-			actual:   len(call.Args),
+			Function: fn.name(),
+			Expected: strconv.Itoa(len(fn.ArgNames)),
+			Actual:   len(args),
 		}
 	}
 
-	scope := NewScope("func", s, s.PrintNulls)
-	for idx, arg := range fn.ArgNames {
-		if nestedCall, ok := call.Args[idx].(*Call); ok && nestedCall.Name == "lambda" {
-			argNames, body, err := makeLambdaFunc(nestedCall)
-			if err != nil {
-				return nil, fmt.Errorf("invalid lambda at argument %d: %w", idx, err)
-			}
-			scope.SetFunc(arg, argNames, body)
-			continue
+	// the body sees the variables of the scope the function was defined
+	// in, not the ones of the caller
+	if err := s.exec.alloc(len(args)); err != nil {
+		return nil, err
+	}
+
+	scope := NewScope("func", fn.Scope, s.PrintNulls)
+	if fn.Params == nil {
+		// the arguments are copied, as the caller may hold them, e.g. as
+		// the elements of a list
+		scope.index, scope.slots = fn.slotIndex(), append([]Expression(nil), args...)
+		return scope, scope.checkPre(fn, args)
+	}
+
+	binds := map[string]Expression{}
+	for idx, param := range fn.Params {
+		ok, err := scope.matchPattern(param, args[idx], binds)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", idx, err)
 		}
+		if !ok {
+			return nil, fmt.Errorf("argument %d: %s: %w", idx, args[idx].FString(), ErrNoMatch)
+		}
+	}
+	for name, val := range binds {
+		scope.SetVar(name, val)
+	}
+
+	return scope, scope.checkPre(fn, args)
+}
+
+// applyBuiltin calls the builtin with the values bound to the variables of
+// a temporary scope, as builtins evaluate their arguments themselves.
+func (s *Scope) applyBuiltin(fn *Builtin, args []Expression) (Expression, error) {
+	scope := NewScope("let", s, s.PrintNulls)
+	call := &Call{Name: fn.Name, Args: make([]Expression, len(args))}
+	for idx, arg := range args {
+		name := "#" + strconv.Itoa(idx)
+		scope.SetVar(name, arg)
+		call.Args[idx] = &Identifier{Name: name}
+	}
+	res, err := builtinMethods[fn.Name](scope, call)
+	if err != nil {
+		return nil, attribute(fn.Name, nil, err)
+	}
+	return res, nil
+}
 
-		argval, err := s.Eval(call.Args[idx])
+func (s *Scope) evalArgs(exprs []Expression) ([]Expression, error) {
+	args := make([]Expression, len(exprs))
+	for idx, expr := range exprs {
+		val, err := s.Eval(expr)
 		if err != nil {
 			return nil, fmt.Errorf("evaluate argument %d: %w", idx, err)
 		}
-		scope.SetVar(arg, argval)
+		args[idx] = val
 	}
+	return args, nil
+}
 
-	result, err := scope.Eval(fn.Body)
+func isCallable(expr Expression) bool {
+	switch expr.(type) {
+	case *Closure, *Builtin, *Native:
+		return true
+	}
+	return false
+}
+
+// bind evaluates the value of the binding in the scope and binds the
+// result to the name, or to the variables of the pattern, in the target
+// scope.
+func (s *Scope) bind(target *Scope, b binding) error {
+	val, err := s.Eval(b.value)
 	if err != nil {
-		return nil, fmt.Errorf("evaluate function %s body: %w", call.Name, err)
+		return fmt.Errorf("evaluate %s: %w", b.name, err)
 	}
 
-	if call, ok := fn.Body.(*Call); ok {
-		if call.Name == "prog" {
-			return scope.Return, nil
-		}
+	if b.pattern == nil {
+		target.SetVar(b.name, val)
+		return nil
 	}
 
-	return result, nil
+	binds := map[string]Expression{}
+	ok, err := s.matchPattern(b.pattern, val, binds)
+	if err != nil {
+		return fmt.Errorf("bind %s: %w", b.name, err)
+	}
+	if !ok {
+		return fmt.Errorf("bind %s: %s: %w", b.name, val.FString(), ErrNoMatch)
+	}
+	for name, v := range binds {
+		target.SetVar(name, v)
+	}
+	return nil
 }
 
-func makeLambdaFunc(call *Call) ([]string, Expression, error) {
+func makeLambdaFunc(call *Call) ([]string, []Expression, Expression, error) {
 	if len(call.Args) != 2 {
-		return nil, nil, ErrInvalidArguments{expected: "2", actual: len(call.Args)}
+		return nil, nil, nil, ErrInvalidArguments{Expected: "2", Actual: len(call.Args)}
 	}
 
 	argListExpr, ok := call.Args[0].(*List)
 	if !ok {
-		return nil, nil, ErrArgumentType{expected: "list", actual: call.Args[0].Type()}
+		return nil, nil, nil, ErrArgumentType{Arg: 1, Expected: "list", Actual: call.Args[0].Type()}
 	}
 
-	argnames := make([]string, len(argListExpr.Values))
-	for idx, expr := range argListExpr.Values {
-		identifier, ok := expr.(*Identifier)
-		if !ok {
-			return nil, nil, ErrArgumentType{expected: "identifier", actual: expr.Type()}
-		}
+	argnames, params, err := castParams(argListExpr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resolveFunc(call, argListExpr.Values, 1)
+	return argnames, params, call.Args[1], nil
+}
 
-		argnames[idx] = identifier.Name
+// castParams returns the names of the arguments and, if some of them are
+// patterns, e.g. (cons h t), the patterns of all arguments.
+func castParams(list *List) ([]string, []Expression, error) {
+	var (
+		argnames = make([]string, len(list.Values))
+		patterns bool
+	)
+
+	for idx, expr := range list.Values {
+		switch expr := expr.(type) {
+		case *Identifier:
+			argnames[idx] = expr.Name
+		case *Call, *List, *Integer, *Rational, *Number, *String, *Symbol, *Boolean, Null:
+			argnames[idx], patterns = sourceString(expr), true
+		default:
+			return nil, nil, fmt.Errorf("argument %d: %w", idx, ErrArgumentType{Expected: "identifier or pattern", Actual: expr.Type()})
+		}
 	}
 
-	return argnames, call.Args[1], nil
+	if !patterns {
+		return argnames, nil, nil
+	}
+	return argnames, list.Values, nil
 }
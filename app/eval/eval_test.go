@@ -0,0 +1,2044 @@
+package eval_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/lexer"
+	"github.com/cappuccinotm/flangc/app/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run evaluates the program in a fresh scope and returns the value of its
+// last expression.
+func run(t *testing.T, src string) (eval.Expression, error) {
+	t.Helper()
+	return runWithLimits(t, src, eval.Limits{})
+}
+
+func runWithLimits(t *testing.T, src string, limits eval.Limits) (eval.Expression, error) {
+	t.Helper()
+
+	p := parser.NewParser(lexer.NewLexer(strings.NewReader(src)))
+	scope := eval.NewScope("", nil, false)
+	scope.SetLimits(limits)
+
+	var result eval.Expression = eval.Null{}
+	for {
+		expr, err := p.ParseNext()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		require.NoError(t, err)
+
+		if result, err = scope.Eval(expr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestScope_Let(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "let values see the enclosing scope",
+			src:  "(setq x 10) (let ((x 1) (y (plus x 1))) (times x y))",
+			want: &eval.Number{Value: 11},
+		},
+		{
+			name: "let* values see previous bindings",
+			src:  "(setq x 10) (let* ((x 1) (y (plus x 1))) (times x y))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "let body sees variables of the enclosing scope",
+			src:  "(func f (a) (let ((b 2)) (plus a b))) (f 1)",
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "inner let shadows outer one",
+			src:  "(let ((x 1)) (let ((x 2)) x))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "bindings don't leak out of let",
+			src:  "(setq x 10) (let ((x 1)) x) (plus x 0)",
+			want: &eval.Number{Value: 10},
+		},
+		{
+			name: "body of several expressions",
+			src:  "(let ((x 1)) (setq x (plus x 1)) (times x 5))",
+			want: &eval.Number{Value: 10},
+		},
+		{
+			name: "empty body",
+			src:  "(let ((x 1)))",
+			want: eval.Null{},
+		},
+		{
+			name: "letrec with mutually recursive lambdas",
+			src: `(letrec (
+				(isEven (lambda (n) (cond (equal n 0) true (isOdd (minus n 1)))))
+				(isOdd (lambda (n) (cond (equal n 0) false (isEven (minus n 1)))))
+			) (isOdd 7))`,
+			want: &eval.Boolean{Value: true},
+		},
+		{
+			name: "letrec values see lambdas bound after them",
+			src: `(letrec (
+				(x (double 2))
+				(double (lambda (n) (times n 2)))
+			) x)`,
+			want: &eval.Number{Value: 4},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_LetErrors(t *testing.T) {
+	_, err := run(t, "(let ((x 1) (y x)) y)")
+	assert.ErrorAs(t, err, &eval.ErrUndefined{})
+
+	_, err = run(t, "(let ((x 1)) y)")
+	assert.ErrorAs(t, err, &eval.ErrUndefined{})
+
+	_, err = eval.NewScope("", nil, false).Eval(&eval.Call{
+		Name: "let",
+		Args: []eval.Expression{&eval.List{Values: []eval.Expression{&eval.Identifier{Name: "x"}}}},
+	})
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+}
+
+func TestScope_Cond(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "if-then", src: "(cond true 1)", want: &eval.Number{Value: 1}},
+		{name: "if-then-else", src: "(cond false 1 2)", want: &eval.Number{Value: 2}},
+		{name: "no else", src: "(cond false 1)", want: eval.Null{}},
+		{
+			name: "first true clause",
+			src:  "(setq x 5) (cond ((less x 0) 1) ((less x 10) 2) ((less x 100) 3) (else 4))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "else clause",
+			src:  "(setq x 500) (cond ((less x 0) 1) ((less x 100) 2) (else 3))",
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "variable as a test",
+			src:  "(setq x false) (setq y true) (cond ((not y) 0) (x 1) (y 2) (else 3))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "variable as a first test with else",
+			src:  "(setq x false) (cond (x 1) (else 2))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "clause without a body",
+			src:  "(cond (false 1) ((equal 1 1)) (else 2))",
+			want: &eval.Boolean{Value: true},
+		},
+		{
+			name: "body of several expressions",
+			src:  "(setq x 1) (cond (true (setq x 2) (plus x 1)) (else 0))",
+			want: &eval.Number{Value: 3},
+		},
+		{name: "no clause matched", src: "(cond (false 1) (false 2))", want: eval.Null{}},
+		{
+			name: "clauses without else",
+			src:  "(setq x 5) (cond ((less x 0) 1) ((less x 10) 2))",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "calls without else",
+			src:  "(func pos (a) (greater a 0)) (cond (pos 1) (plus 1 1) (plus 2 2))",
+			want: &eval.Integer{Value: 2},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_Case(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "single datum", src: "(case 1 (0 10) (1 11))", want: &eval.Number{Value: 11}},
+		{name: "data list", src: "(case 3 ((1 2) 10) ((3 4) 11))", want: &eval.Number{Value: 11}},
+		{name: "booleans", src: "(case (less 1 2) (false 10) (true 11))", want: &eval.Number{Value: 11}},
+		{name: "list datum", src: "(case '(1 2) (('(1 2)) 10) (else 11))", want: &eval.Number{Value: 10}},
+		{name: "else", src: "(case 5 ((1 2) 10) (else 11))", want: &eval.Number{Value: 11}},
+		{name: "no match", src: "(case 5 ((1 2) 10))", want: eval.Null{}},
+		{name: "key is evaluated", src: "(setq x 2) (case (plus x 1) (3 10))", want: &eval.Number{Value: 10}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_MalformedClauses(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+	}{
+		{name: "cond else is not last", src: "(cond (false 1) (else 2) (true 3))"},
+		{name: "case else is not last", src: "(case 1 (else 2) (1 3))"},
+		{name: "case non-literal datum", src: "(case 1 (((plus 1 0)) 2))"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.src)
+			assert.ErrorAs(t, err, &eval.ErrInvalidClause{})
+		})
+	}
+
+	_, err := run(t, "(cond (1 2) (else 3))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+}
+
+func TestScope_ShadowBuiltins(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+		err  error
+	}{
+		{
+			name: "function",
+			src:  "(func apply (f x) (f x)) (apply (lambda (a) (plus a 1)) 1)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "parameter",
+			src:  "(func f (map) (map 3)) (f (lambda (x) (times x 2)))",
+			want: &eval.Integer{Value: 6},
+		},
+		{
+			name: "builtin outside of the scope",
+			src:  "(func f (max) (plus max 1)) (f 1) (max 1 2)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "let",
+			src:  "(let ((sort (lambda (l) 'sorted))) (sort '(2 1)))",
+			want: &eval.Symbol{Name: "sorted"},
+		},
+		{name: "variable", src: "(setq reverse 1) (plus reverse 1)", want: &eval.Integer{Value: 2}},
+		{name: "special form", src: "(func and (a b) a)", err: eval.ErrReserved{Name: "and"}},
+		{name: "assign a builtin", src: "(set! length 1)", err: eval.ErrReserved{Name: "length"}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_While(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "predicate is evaluated before each iteration",
+			src:  "(setq x 0) (while (less x 10) (setq x (plus x 1))) (plus x 0)",
+			want: &eval.Number{Value: 10},
+		},
+		{
+			name: "false predicate",
+			src:  "(setq x 0) (while (less x 0) (setq x (plus x 1))) (plus x 0)",
+			want: &eval.Number{Value: 0},
+		},
+		{
+			name: "break",
+			src:  "(setq x 0) (while true (setq x (plus x 1)) (cond (equal x 3) (break))) (plus x 0)",
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "break from nested prog",
+			src: `(setq x 0)
+				(while true (setq x (plus x 1)) (prog (x) ((cond (equal x 3) (break)))))
+				(plus x 0)`,
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "continue",
+			src: `(setq x 0) (setq n 0)
+				(while (less x 5) (setq x (plus x 1)) (cond (equal x 2) (continue)) (setq n (plus n x)))
+				(plus n 0)`,
+			want: &eval.Number{Value: 13},
+		},
+		{
+			name: "continue from nested prog",
+			src: `(setq x 0) (setq n 0)
+				(while (less x 5) (setq x (plus x 1)) (prog (x) ((cond (equal x 2) (continue)))) (setq n (plus n x)))
+				(plus n 0)`,
+			want: &eval.Number{Value: 13},
+		},
+		{
+			name: "nested loops",
+			src: `(setq i 0) (setq n 0)
+				(while (less i 3)
+					(setq i (plus i 1))
+					(setq j 0)
+					(while true (setq j (plus j 1)) (setq n (plus n 1)) (cond (equal j 2) (break))))
+				(plus n 0)`,
+			want: &eval.Number{Value: 6},
+		},
+		{
+			name: "return from the loop",
+			src: `(func f (n) (prog (n) (
+					(setq i 0)
+					(while true (setq i (plus i 1)) (cond (equal i n) (return (times i 10))))
+					(return 0)
+				)))
+				(f 4)`,
+			want: &eval.Number{Value: 40},
+		},
+		{
+			name: "return from nested prog blocks",
+			src: `(func f (n) (prog (n) (
+					(prog (n) ((prog (n) ((return n))) (return 1)))
+					(return 2)
+				)))
+				(f 5)`,
+			want: &eval.Number{Value: 5},
+		},
+		{
+			name: "function body is a loop",
+			src:  "(func f (n) (while true (return n))) (f 3)",
+			want: &eval.Number{Value: 3},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_LoopControlOutsideLoop(t *testing.T) {
+	_, err := run(t, "(break)")
+	assert.ErrorIs(t, err, eval.ErrInvalidContext)
+
+	_, err = run(t, "(continue)")
+	assert.ErrorIs(t, err, eval.ErrInvalidContext)
+
+	_, err = run(t, "(func f () (break)) (while true (f))")
+	assert.ErrorIs(t, err, eval.ErrInvalidContext)
+}
+
+func TestScope_Iteration(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "for-each",
+			src:  "(setq n 0) (for-each (x '(1 2 3)) (setq n (plus (times n 10) x))) (plus n 0)",
+			want: &eval.Number{Value: 123},
+		},
+		{
+			name: "for-each over empty list",
+			src:  "(setq n 0) (for-each (x '()) (setq n 1)) (plus n 0)",
+			want: &eval.Number{Value: 0},
+		},
+		{
+			name: "list is evaluated once",
+			src: `(setq l '(1 2 3)) (setq n 0)
+				(for-each (x l) (setq l (cons x l)) (setq n (plus n 1)))
+				(plus n 0)`,
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "dotimes",
+			src:  "(setq n 0) (dotimes (i 4) (setq n (plus n i))) (plus n 0)",
+			want: &eval.Number{Value: 6},
+		},
+		{
+			name: "for",
+			src:  "(setq n 0) (for (i 1 4) (setq n (plus (times n 10) i))) (plus n 0)",
+			want: &eval.Number{Value: 123},
+		},
+		{
+			name: "for with step",
+			src:  "(setq n 0) (for (i 0 10 3) (setq n (plus (times n 10) i))) (plus n 0)",
+			want: &eval.Number{Value: 369},
+		},
+		{
+			name: "for with negative step",
+			src:  "(setq n 0) (for (i 3 0 (minus 0 1)) (setq n (plus (times n 10) i))) (plus n 0)",
+			want: &eval.Number{Value: 321},
+		},
+		{
+			name: "fresh binding per iteration",
+			src:  "(setq n 0) (dotimes (i 3) (setq i (plus i 10)) (setq n (plus n 1))) (plus n 0)",
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "loop variable is gone after the loop",
+			src:  "(setq i 42) (dotimes (i 3) (print i)) (plus i 0)",
+			want: &eval.Number{Value: 42},
+		},
+		{
+			name: "break and continue",
+			src: `(setq n 0)
+				(for (i 0 100)
+					(cond (equal i 1) (continue))
+					(cond (equal i 4) (break))
+					(setq n (plus (times n 10) i)))
+				(plus n 0)`,
+			want: &eval.Number{Value: 23},
+		},
+		{
+			name: "break out of the inner loop",
+			src: `(setq n 0)
+				(dotimes (i 3) (dotimes (j 3) (cond (equal j 1) (break)) (setq n (plus n 1))))
+				(plus n 0)`,
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "return from nested prog in the loop",
+			src: `(func find (l v) (prog (l v) (
+					(for-each (x l) (prog (x v) ((cond (equal x v) (return true)))))
+					(return false)
+				)))
+				(find '(1 2 3) 2)`,
+			want: &eval.Boolean{Value: true},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	_, err := run(t, "(for (i 0 10 0) (print i))")
+	assert.ErrorIs(t, err, eval.ErrZeroStep)
+
+	_, err = run(t, "(for-each (x 1) (print x))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	_, err = run(t, "(dotimes (i 1 2) (print i))")
+	assert.ErrorAs(t, err, &eval.ErrInvalidArguments{})
+}
+
+func TestScope_Closures(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "returned lambda keeps the arguments of the function",
+			src: `(func adder (n) (lambda (x) (plus x n)))
+				(setq addFive (adder 5))
+				(addFive 10)`,
+			want: &eval.Number{Value: 15},
+		},
+		{
+			name: "partial application",
+			src: `(func partial (f x) (lambda (y) (f x y)))
+				(func mul (a b) (times a b))
+				(setq double (partial mul 2))
+				(double 21)`,
+			want: &eval.Number{Value: 42},
+		},
+		{
+			name: "closures don't share their environments",
+			src: `(func adder (n) (lambda (x) (plus x n)))
+				(setq addOne (adder 1))
+				(setq addTen (adder 10))
+				(plus (addOne 0) (addTen 0))`,
+			want: &eval.Number{Value: 11},
+		},
+		{
+			name: "function sees global variables",
+			src:  "(setq k 3) (func f (x) (times x k)) (f 2)",
+			want: &eval.Number{Value: 6},
+		},
+		{
+			name: "lambda captures let bindings",
+			src:  "(setq f (let ((k 3)) (lambda (x) (times x k)))) (f 2)",
+			want: &eval.Number{Value: 6},
+		},
+		{
+			name: "named function passed as an argument",
+			src: `(func twice (f x) (f (f x)))
+				(func inc (x) (plus x 1))
+				(twice inc 5)`,
+			want: &eval.Number{Value: 7},
+		},
+		{
+			name: "function defined inside a function",
+			src: `(func outer (n) (prog (n) (
+					(func inner (x) (plus x n))
+					(return (inner 1))
+				)))
+				(outer 41)`,
+			want: &eval.Number{Value: 42},
+		},
+		{
+			name: "lambda sees itself through letrec",
+			src: `(letrec ((fact (lambda (n) (cond (equal n 0) 1 (times n (fact (minus n 1)))))))
+				(fact 5))`,
+			want: &eval.Number{Value: 120},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_LexicalScoping(t *testing.T) {
+	// function doesn't see the variables of its caller
+	_, err := run(t, `(func f () (plus y 1)) (func g (y) (f)) (g 1)`)
+	assert.ErrorAs(t, err, &eval.ErrUndefined{})
+
+	// lambda is evaluated in the environment it was created in
+	res, err := run(t, `(setq n 1)
+		(setq f (lambda () n))
+		(func g (n) (f))
+		(g 100)`)
+	require.NoError(t, err)
+	assert.True(t, (&eval.Number{Value: 1}).Equal(res), "got %s", res)
+}
+
+func TestScope_FirstClassFunctions(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "lambda stored in a variable",
+			src:  "(setq inc (lambda (x) (plus x 1))) (inc 1)",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "named function assigned to a variable",
+			src:  "(func inc (x) (plus x 1)) (setq f inc) (f 1)",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "functions stored in a list",
+			src: `(func inc (x) (plus x 1))
+				(setq fs (cons inc (cons (lambda (x) (times x 2)) null)))
+				(plus ((head fs) 10) ((head (tail fs)) 10))`,
+			want: &eval.Number{Value: 31},
+		},
+		{
+			name: "builtin as a value",
+			src:  "(setq add plus) (add 1 2)",
+			want: &eval.Number{Value: 3},
+		},
+		{
+			name: "builtin passed as an argument",
+			src:  "(func fold (f acc l) (cond (empty l) acc (fold f (f acc (head l)) (tail l)))) (fold times 1 '(1 2 3 4))",
+			want: &eval.Number{Value: 24},
+		},
+		{
+			name: "function call of an expression",
+			src:  "((lambda (x y) (minus x y)) 5 3)",
+			want: &eval.Number{Value: 2},
+		},
+		{
+			name: "funcall",
+			src:  "(func inc (x) (plus x 1)) (funcall inc 41)",
+			want: &eval.Number{Value: 42},
+		},
+		{
+			name: "apply a closure",
+			src:  "(apply (lambda (a b c) (plus a (times b c))) '(1 2 3))",
+			want: &eval.Number{Value: 7},
+		},
+		{
+			name: "apply a builtin",
+			src:  "(apply cons (cons 1 (cons '(2 3) null)))",
+			want: &eval.List{Values: []eval.Expression{&eval.Number{Value: 1}, &eval.Number{Value: 2}, &eval.Number{Value: 3}}},
+		},
+		{
+			name: "apply with no arguments",
+			src:  "(apply (lambda () 5) null)",
+			want: &eval.Number{Value: 5},
+		},
+		{
+			name: "function is equal to itself",
+			src:  "(func f (x) x) (setq g f) (equal f g)",
+			want: &eval.Boolean{Value: true},
+		},
+		{name: "isfunc on a closure", src: "(func f (x) x) (isfunc f)", want: &eval.Boolean{Value: true}},
+		{name: "isfunc on a builtin", src: "(isfunc plus)", want: &eval.Boolean{Value: true}},
+		{name: "isfunc on a number", src: "(setq x 1) (isfunc x)", want: &eval.Boolean{Value: false}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_CallErrors(t *testing.T) {
+	_, err := run(t, "(setq x 1) (x 2)")
+	assert.ErrorAs(t, err, &eval.ErrNotFunction{})
+
+	_, err = run(t, "(funcall 1 2)")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	_, err = run(t, "(apply (lambda (x) x) '(1 2))")
+	assert.ErrorAs(t, err, &eval.ErrInvalidArguments{})
+
+	_, err = run(t, "(func cond (a b) a)")
+	assert.ErrorAs(t, err, &eval.ErrReserved{})
+
+	_, err = run(t, "(undefinedFunction 1)")
+	assert.ErrorAs(t, err, &eval.ErrUndefined{})
+}
+
+func TestScope_TailCalls(t *testing.T) {
+	// every call is logged, which takes most of the time at this depth
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	const depth = "1000000"
+
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{
+			name: "cond branch",
+			src: "(func count (n acc) (cond (equal n 0) acc (count (minus n 1) (plus acc 1))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+		{
+			name: "cond clause",
+			src: "(func count (n acc) (cond ((equal n 0) acc) (else (count (minus n 1) (plus acc 1)))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+		{
+			name: "return from prog",
+			src: "(func count (n acc) (prog (n acc) ((cond (equal n 0) (return acc)) " +
+				"(return (count (minus n 1) (plus acc 1))))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+		{
+			name: "return from a loop",
+			src: "(func count (n acc) (while true (cond (equal n 0) (return acc)) " +
+				"(return (count (minus n 1) (plus acc 1)))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+		{
+			name: "let body",
+			src: "(func count (n acc) (cond (equal n 0) acc (let ((m (minus n 1))) (count m (plus acc 1)))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+		{
+			name: "mutual recursion",
+			src: "(func isEven (n) (cond (equal n 0) true (isOdd (minus n 1))))" +
+				"(func isOdd (n) (cond (equal n 0) false (isEven (minus n 1))))" +
+				"(isEven " + depth + ")",
+			want: &eval.Boolean{Value: true},
+		},
+		{
+			name: "funcall",
+			src: "(func count (n acc) (cond (equal n 0) acc (funcall count (minus n 1) (plus acc 1))))" +
+				"(count " + depth + " 0)",
+			want: &eval.Number{Value: 1000000},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_Limits(t *testing.T) {
+	tbl := []struct {
+		name   string
+		src    string
+		limits eval.Limits
+		limit  string
+	}{
+		{
+			name:   "deep recursion",
+			src:    "(func f (n) (cond (equal n 0) 0 (plus 1 (f (minus n 1))))) (f 1000)",
+			limits: eval.Limits{MaxDepth: 100},
+			limit:  "depth",
+		},
+		{
+			name:   "infinite loop",
+			src:    "(while true (setq x 1))",
+			limits: eval.Limits{MaxSteps: 1000},
+			limit:  "steps",
+		},
+		{
+			name:   "infinite tail recursion",
+			src:    "(func f (n) (f n)) (f 1)",
+			limits: eval.Limits{MaxSteps: 1000},
+			limit:  "steps",
+		},
+		{
+			name:   "growing list",
+			src:    "(setq l null) (while true (setq l (cons 1 l)))",
+			limits: eval.Limits{MaxValues: 10000},
+			limit:  "values",
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runWithLimits(t, tt.src, tt.limits)
+			var limitErr eval.ErrLimitExceeded
+			require.ErrorAs(t, err, &limitErr)
+			assert.Equal(t, tt.limit, limitErr.Limit)
+		})
+	}
+
+	t.Run("tail calls don't add to depth", func(t *testing.T) {
+		res, err := runWithLimits(t, "(func f (n) (cond (equal n 0) 0 (f (minus n 1)))) (f 1000)",
+			eval.Limits{MaxDepth: 10})
+		require.NoError(t, err)
+		assert.True(t, (&eval.Number{Value: 0}).Equal(res))
+	})
+
+	t.Run("statements aren't counted as values", func(t *testing.T) {
+		scope := eval.NewScope("", nil, false)
+		scope.SetLimits(eval.Limits{MaxValues: 1})
+		_, err := runIn(t, scope, "(setq x 1) (setq y (plus x 1)) (print y) (setq z 3)")
+		require.NoError(t, err)
+
+		_, err = runIn(t, scope, "(setq w (plus x 2))")
+		var limitErr eval.ErrLimitExceeded
+		require.ErrorAs(t, err, &limitErr)
+		_, err = scope.GetVar("w", true)
+		assert.ErrorIs(t, err, eval.ErrUndefined{Name: "w"}, "the failed statement has no effect")
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		res, err := runWithLimits(t, "(func f (n) (cond (equal n 0) 0 (plus 1 (f (minus n 1))))) (f 50)",
+			eval.Limits{MaxDepth: 100, MaxSteps: 10000, MaxValues: 10000})
+		require.NoError(t, err)
+		assert.True(t, (&eval.Number{Value: 50}).Equal(res))
+	})
+}
+
+func TestScope_EvalContext(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+	}{
+		{name: "infinite loop", src: "(while true null)"},
+		{name: "infinite tail recursion", src: "(func f (n) (f n)) (f 1)"},
+		{name: "infinite for-each", src: "(func f (n) (for-each (x '(1 2 3)) (f n))) (f 1)"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			p := parser.NewParser(lexer.NewLexer(strings.NewReader(tt.src)))
+			scope := eval.NewScope("", nil, false)
+
+			var err error
+			for {
+				var expr eval.Expression
+				if expr, err = p.ParseNext(); errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+
+				if _, err = scope.EvalContext(ctx, expr); err != nil {
+					break
+				}
+			}
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+
+	t.Run("canceled before the call", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		scope := eval.NewScope("", nil, false)
+		_, err := scope.EvalContext(ctx, &eval.Call{Name: "plus", Args: []eval.Expression{
+			&eval.Number{Value: 1}, &eval.Number{Value: 2},
+		}})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Contains(t, err.Error(), `call "plus"`)
+	})
+}
+
+func TestScope_Logic(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "and of none", src: "(and)", want: &eval.Boolean{Value: true}},
+		{name: "or of none", src: "(or)", want: &eval.Boolean{Value: false}},
+		{name: "and of three", src: "(and true true true)", want: &eval.Boolean{Value: true}},
+		{name: "and with false", src: "(and true false true)", want: &eval.Boolean{Value: false}},
+		{name: "or of three", src: "(or false false true)", want: &eval.Boolean{Value: true}},
+		{
+			name: "and stops at false",
+			src:  "(setq l '()) (and (not (empty l)) (equal (head l) 1))",
+			want: &eval.Boolean{Value: false},
+		},
+		{
+			name: "or stops at true",
+			src:  "(setq l '()) (or (empty l) (equal (head l) 1))",
+			want: &eval.Boolean{Value: true},
+		},
+		{
+			name: "operands are evaluated in order",
+			src:  "(setq x 0) (and (let () (setq x (plus x 1)) true) false (let () (setq x 10) true)) (plus x 0)",
+			want: &eval.Number{Value: 1},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	_, err := run(t, "(and true 1)")
+	var typeErr eval.ErrArgumentType
+	require.ErrorAs(t, err, &typeErr)
+	assert.Contains(t, typeErr.Error(), "expected argument of type boolean")
+}
+
+func TestScope_Numbers(t *testing.T) {
+	fact25, _ := new(big.Int).SetString("15511210043330985984000000", 10)
+
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "integer literal", src: "(plus 0 42)", want: &eval.Integer{Value: 42}},
+		{name: "float literal", src: "(plus 0 1.5)", want: &eval.Number{Value: 1.5}},
+		{
+			name: "promotion to big integer",
+			src:  "(func f (n) (cond (equal n 0) 1 (times n (f (minus n 1))))) (f 25)",
+			want: eval.NewInteger(fact25),
+		},
+		{
+			name: "demotion from big integer",
+			src:  "(setq x (times 9223372036854775807 2)) (minus x 9223372036854775807)",
+			want: &eval.Integer{Value: 9223372036854775807},
+		},
+		{name: "exact division", src: "(divide 1 3)", want: eval.NewRational(big.NewRat(1, 3))},
+		{name: "integral division", src: "(divide 6 3)", want: &eval.Integer{Value: 2}},
+		{name: "rational sum", src: "(plus (divide 1 3) (divide 2 3))", want: &eval.Integer{Value: 1}},
+		{name: "rational literal", src: "(plus 1/3 2/6)", want: eval.NewRational(big.NewRat(2, 3))},
+		{name: "rational and float", src: "(plus (divide 1 2) 0.25)", want: &eval.Number{Value: 0.75}},
+		{name: "integer and float", src: "(times 2 1.5)", want: &eval.Number{Value: 3}},
+		{name: "comparison across kinds", src: "(less (divide 1 3) 0.34)", want: &eval.Boolean{Value: true}},
+		{name: "equality across kinds", src: "(equal 2 2.0)", want: &eval.Boolean{Value: true}},
+		{name: "quotient", src: "(quotient 17 5)", want: &eval.Integer{Value: 3}},
+		{name: "quotient of negative", src: "(quotient (minus 0 17) 5)", want: &eval.Integer{Value: -3}},
+		{name: "remainder", src: "(remainder (minus 0 7) 2)", want: &eval.Integer{Value: -1}},
+		{name: "modulo", src: "(modulo (minus 0 7) 2)", want: &eval.Integer{Value: 1}},
+		{name: "gcd", src: "(gcd 12 (minus 0 18))", want: &eval.Integer{Value: 6}},
+		{name: "float", src: "(float (divide 1 4))", want: &eval.Number{Value: 0.25}},
+		{name: "exact", src: "(exact 0.75)", want: eval.NewRational(big.NewRat(3, 4))},
+		{name: "numerator", src: "(numerator (divide 6 4))", want: &eval.Integer{Value: 3}},
+		{name: "denominator", src: "(denominator (divide 6 4))", want: &eval.Integer{Value: 2}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+			assert.Equal(t, tt.want.String(), res.String())
+		})
+	}
+
+	_, err := run(t, "(divide 1 0)")
+	assert.ErrorIs(t, err, eval.ErrZeroDivision)
+
+	_, err = run(t, "(modulo 1 0)")
+	assert.ErrorIs(t, err, eval.ErrZeroDivision)
+
+	_, err = run(t, "(quotient 1.5 2)")
+	var typeErr eval.ErrArgumentType
+	require.ErrorAs(t, err, &typeErr)
+	assert.Contains(t, typeErr.Error(), "expected argument of type integer, got float")
+}
+
+func TestScope_Math(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "exact sqrt", src: "(sqrt 16)", want: &eval.Integer{Value: 4}},
+		{name: "exact sqrt of rational", src: "(sqrt (divide 4 9))", want: eval.NewRational(big.NewRat(2, 3))},
+		{name: "inexact sqrt", src: "(sqrt 2)", want: &eval.Number{Value: math.Sqrt2}},
+		{name: "exact pow", src: "(pow 2 100)", want: eval.NewInteger(new(big.Int).Lsh(big.NewInt(1), 100))},
+		{name: "negative pow", src: "(pow 2 (minus 0 2))", want: eval.NewRational(big.NewRat(1, 4))},
+		{name: "float pow", src: "(pow 4 0.5)", want: &eval.Number{Value: 2}},
+		{name: "exp", src: "(exp 0)", want: &eval.Number{Value: 1}},
+		{name: "log", src: "(log e)", want: &eval.Number{Value: 1}},
+		{name: "sin", src: "(sin 0)", want: &eval.Number{Value: 0}},
+		{name: "cos", src: "(cos pi)", want: &eval.Number{Value: -1}},
+		{name: "atan2", src: "(atan 1 1)", want: &eval.Number{Value: math.Pi / 4}},
+		{name: "floor of rational", src: "(floor (divide (minus 0 7) 2))", want: &eval.Integer{Value: -4}},
+		{name: "ceil of rational", src: "(ceil (divide 7 2))", want: &eval.Integer{Value: 4}},
+		{name: "round half to even", src: "(round (divide 5 2))", want: &eval.Integer{Value: 2}},
+		{name: "round of float", src: "(round 3.5)", want: &eval.Number{Value: 4}},
+		{name: "round of float half to even", src: "(round 2.5)", want: &eval.Number{Value: 2}},
+		{name: "truncate", src: "(truncate (divide (minus 0 7) 2))", want: &eval.Integer{Value: -3}},
+		{name: "floor of float stays float", src: "(floor 2.5)", want: &eval.Number{Value: 2}},
+		{name: "abs", src: "(abs (minus 0 5))", want: &eval.Integer{Value: 5}},
+		{name: "min", src: "(min 3 1.5 2)", want: &eval.Number{Value: 1.5}},
+		{name: "max", src: "(max 3 (divide 7 2) 2)", want: eval.NewRational(big.NewRat(7, 2))},
+		{name: "constants may be shadowed", src: "(setq e 5) (plus e 0)", want: &eval.Integer{Value: 5}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+			assert.Equal(t, tt.want.String(), res.String())
+		})
+	}
+
+	for _, src := range []string{
+		"(sqrt (minus 0 1))", "(log 0)", "(asin 2)", "(random 0)",
+		// the exact powers too large to compute in time
+		"(pow 2 1000000000)", "(pow 1/3 (minus 0 1000000000))", "(pow 10 (pow 10 30))",
+	} {
+		_, err := run(t, src)
+		assert.ErrorIs(t, err, eval.ErrDomain, src)
+	}
+
+	for src, want := range map[string]int64{"(pow 1 1000000000)": 1, "(pow (minus 0 1) 1000000001)": -1} {
+		res, err := run(t, src)
+		require.NoError(t, err, src)
+		assert.True(t, (&eval.Integer{Value: want}).Equal(res), src)
+	}
+	res, err := run(t, "(greater (pow 2 1000000) (pow 2 999999))")
+	require.NoError(t, err)
+	assert.Equal(t, &eval.Boolean{Value: true}, res)
+
+	for _, src := range []string{
+		"(exp 1000)", "(pow 10.0 400)", "(pow 10 400.5)", "(float (pow 10 400))",
+		"(times (float (pow 10 300)) (pow 10 300))", "(plus (float (pow 10 308)) (pow 10 308))",
+		"(minus (float (minus 0 (pow 10 308))) (pow 10 308))",
+		"(divide (float (pow 10 300)) (divide 1 (pow 10 300)))", "(sqrt (plus (pow 10 700) 1))",
+	} {
+		_, err := run(t, src)
+		assert.ErrorIs(t, err, eval.ErrOverflow, src)
+	}
+
+	_, err = run(t, "(pow 0.0 (minus 0 1))")
+	assert.ErrorIs(t, err, eval.ErrZeroDivision)
+}
+
+func TestScope_Random(t *testing.T) {
+	const src = "(cons (random 100) (cons (random) (cons (random 2.5) null)))"
+
+	evalSeeded := func(seed int64) *eval.List {
+		p := parser.NewParser(lexer.NewLexer(strings.NewReader(src)))
+		scope := eval.NewScope("", nil, false)
+		scope.SetSeed(seed)
+
+		expr, err := p.ParseNext()
+		require.NoError(t, err)
+		res, err := scope.Eval(expr)
+		require.NoError(t, err)
+		return res.(*eval.List)
+	}
+
+	res := evalSeeded(42)
+	assert.True(t, res.Equal(evalSeeded(42)), "same seed must give the same numbers")
+
+	require.IsType(t, &eval.Integer{}, res.Values[0])
+	assert.True(t, res.Values[0].(*eval.Integer).Value < 100)
+	require.IsType(t, &eval.Number{}, res.Values[1])
+	assert.True(t, res.Values[1].(*eval.Number).Value < 1)
+	require.IsType(t, &eval.Number{}, res.Values[2])
+	assert.True(t, res.Values[2].(*eval.Number).Value < 2.5)
+}
+
+func TestScope_Lists(t *testing.T) {
+	list := func(values ...int64) eval.Expression {
+		l := &eval.List{}
+		for _, v := range values {
+			l.Values = append(l.Values, &eval.Integer{Value: v})
+		}
+		return l
+	}
+
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "length", src: "(length '(1 2 3))", want: &eval.Integer{Value: 3}},
+		{name: "length of null", src: "(length null)", want: &eval.Integer{Value: 0}},
+		{name: "nth", src: "(nth 1 '(1 2 3))", want: &eval.Integer{Value: 2}},
+		{name: "last", src: "(last '(1 2 3))", want: &eval.Integer{Value: 3}},
+		{name: "take", src: "(take 2 '(1 2 3))", want: list(1, 2)},
+		{name: "take more than there is", src: "(take 5 '(1 2 3))", want: list(1, 2, 3)},
+		{name: "drop", src: "(drop 2 '(1 2 3))", want: list(3)},
+		{name: "append", src: "(append '(1 2) null '(3) '(4 5))", want: list(1, 2, 3, 4, 5)},
+		{name: "reverse", src: "(reverse '(1 2 3))", want: list(3, 2, 1)},
+		{name: "range", src: "(range 5)", want: list(0, 1, 2, 3, 4)},
+		{name: "range with step", src: "(range 1 10 3)", want: list(1, 4, 7)},
+		{name: "range down", src: "(range 3 0 (minus 0 1))", want: list(3, 2, 1)},
+		{
+			name: "zip",
+			src:  "(zip '(1 2 3) '(4 5))",
+			want: &eval.List{Values: []eval.Expression{list(1, 4), list(2, 5)}},
+		},
+		{name: "member", src: "(member 2 '(1 2 3))", want: &eval.Boolean{Value: true}},
+		{name: "not a member", src: "(member 4 '(1 2 3))", want: &eval.Boolean{Value: false}},
+		{name: "assoc", src: "(assoc 2 (cons '(1 10) (cons '(2 20) null)))", want: list(2, 20)},
+		{name: "assoc of missing key", src: "(assoc 3 (cons '(1 10) null))", want: eval.Null{}},
+		{name: "map", src: "(map (lambda (x) (times x 2)) '(1 2 3))", want: list(2, 4, 6)},
+		{name: "map a builtin", src: "(map abs (cons (minus 0 1) '(2)))", want: list(1, 2)},
+		{name: "filter", src: "(filter (lambda (x) (greater x 1)) '(1 2 3))", want: list(2, 3)},
+		{name: "foldl", src: "(foldl minus 10 '(1 2 3))", want: &eval.Integer{Value: 4}},
+		{name: "foldr", src: "(foldr minus 10 '(1 2 3))", want: &eval.Integer{Value: -8}},
+		{name: "reduce", src: "(reduce plus '(1 2 3 4))", want: &eval.Integer{Value: 10}},
+		{name: "sort", src: "(sort less '(3 1 2))", want: list(1, 2, 3)},
+		{name: "sort with a closure", src: "(sort (lambda (a b) (greater a b)) '(3 1 2))", want: list(3, 2, 1)},
+		{name: "head of null-terminated list", src: "(head (cons 1 null))", want: &eval.Integer{Value: 1}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	for _, src := range []string{"(head '())", "(tail '())", "(last null)", "(reduce plus '())"} {
+		_, err := run(t, src)
+		assert.ErrorIs(t, err, eval.ErrEmptyList, src)
+	}
+
+	_, err := run(t, "(nth 2 '(1 2))")
+	assert.ErrorIs(t, err, eval.ErrOutOfRange)
+
+	_, err = run(t, "(map 1 '(1 2))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	_, err = run(t, "(sort plus '(1 2))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+}
+
+func TestScope_MapGrowth(t *testing.T) {
+	const src = `(setq m (hashmap))
+(dotimes (i 2000) (setq m (mapput m i (times i i))))
+(setq full m)
+(dotimes (i 1000) (setq m (mapdel m (times i 2))))
+(setq m (mapput m 1 'one))
+(cons (mapget m 1999) (cons (length (mapkeys m)) (cons (take 3 (mapkeys m))
+	(cons (mapget m 1) (cons (length (mapkeys full)) (cons (mapget full 2) null))))))`
+
+	// a put must not take the values of the whole map
+	res, err := runWithLimits(t, src, eval.Limits{MaxValues: 50000})
+	require.NoError(t, err)
+
+	want := &eval.List{Values: []eval.Expression{
+		&eval.Integer{Value: 3996001},
+		&eval.Integer{Value: 1000},
+		&eval.List{Values: []eval.Expression{&eval.Integer{Value: 1}, &eval.Integer{Value: 3}, &eval.Integer{Value: 5}}},
+		&eval.Symbol{Name: "one"},
+		&eval.Integer{Value: 2000},
+		&eval.Integer{Value: 4},
+	}}
+	assert.True(t, want.Equal(res), "expected %s, got %s", want, res)
+}
+
+func TestScope_Maps(t *testing.T) {
+	sym := func(name string) eval.Expression { return &eval.Symbol{Name: name} }
+	num := func(v int64) eval.Expression { return &eval.Integer{Value: v} }
+	hashmap := func(kvs ...eval.Expression) eval.Expression {
+		var keys, values []eval.Expression
+		for idx := 0; idx < len(kvs); idx += 2 {
+			keys, values = append(keys, kvs[idx]), append(values, kvs[idx+1])
+		}
+		m, err := eval.NewMap(keys, values)
+		require.NoError(t, err)
+		return m
+	}
+
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "get", src: "(mapget (hashmap 'a 1 'b 2) 'b)", want: num(2)},
+		{name: "get missing", src: "(mapget (hashmap 'a 1) 'b)", want: eval.Null{}},
+		{name: "get default", src: "(mapget (hashmap 'a 1) 'b 0)", want: num(0)},
+		{name: "string keys", src: `(mapget (hashmap "a" 1 'a 2) "a")`, want: num(1)},
+		{name: "equal numbers are the same key", src: "(mapget (hashmap 1 'int) 1.0)", want: sym("int")},
+		{name: "later key wins", src: "(hashmap 'a 1 'a 2)", want: hashmap(sym("a"), num(2))},
+		{name: "put", src: "(mapput (hashmap 'a 1) 'b 2)", want: hashmap(sym("a"), num(1), sym("b"), num(2))},
+		{
+			name: "put is persistent",
+			src:  "(let ((m (hashmap 'a 1))) (mapput m 'a 2) (mapget m 'a))",
+			want: num(1),
+		},
+		{name: "delete", src: "(mapdel (hashmap 'a 1 'b 2) 'a)", want: hashmap(sym("b"), num(2))},
+		{name: "delete missing", src: "(mapdel (hashmap 'a 1) 'b)", want: hashmap(sym("a"), num(1))},
+		{name: "has", src: "(maphas (hashmap true 1) true)", want: &eval.Boolean{Value: true}},
+		{name: "has not", src: "(maphas (hashmap true 1) false)", want: &eval.Boolean{Value: false}},
+		{
+			name: "keys in insertion order",
+			src:  "(mapkeys (mapput (hashmap 'b 1 'a 2) 'c 3))",
+			want: &eval.List{Values: []eval.Expression{sym("b"), sym("a"), sym("c")}},
+		},
+		{
+			name: "values",
+			src:  "(mapvalues (hashmap 'b 1 'a 2))",
+			want: &eval.List{Values: []eval.Expression{num(1), num(2)}},
+		},
+		{
+			name: "merge",
+			src:  "(mapmerge (hashmap 'a 1 'b 2) (hashmap 'b 3 'c 4))",
+			want: hashmap(sym("a"), num(1), sym("b"), num(3), sym("c"), num(4)),
+		},
+		{name: "equal in any order", src: "(equal (hashmap 'a 1 'b 2) (hashmap 'b 2 'a 1))", want: &eval.Boolean{Value: true}},
+		{name: "not equal", src: "(equal (hashmap 'a 1) (hashmap 'a 2))", want: &eval.Boolean{Value: false}},
+		{name: "ismap", src: "(ismap (hashmap))", want: &eval.Boolean{Value: true}},
+		{name: "isstring", src: `(isstring "a")`, want: &eval.Boolean{Value: true}},
+		{name: "issymbol", src: "(issymbol 'a)", want: &eval.Boolean{Value: true}},
+		{
+			name: "nested quoted list",
+			src:  "(head '((a 1) (b 2)))",
+			want: &eval.List{Values: []eval.Expression{sym("a"), num(1)}},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	m := hashmap(sym("a"), &eval.String{Value: "x\ny"}, num(1), &eval.List{Values: []eval.Expression{sym("b")}})
+	assert.Equal(t, `(hashmap 'a "x\ny" 1 '(b))`, m.FString())
+	assert.Equal(t, `{a: "x\ny", 1: [b]}`, m.String())
+
+	for _, src := range []string{"(hashmap '(1) 1)", "(mapget '(1) 1)"} {
+		_, err := run(t, src)
+		assert.ErrorAs(t, err, &eval.ErrArgumentType{}, src)
+	}
+
+	// the arithmetic never gives NaN, though the host may
+	scope := eval.NewScope("", nil, false)
+	scope.SetVar("nan", &eval.Number{Value: math.NaN()})
+	_, err := runIn(t, scope, "(mapget (hashmap) nan)")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	_, err = run(t, "(hashmap 'a)")
+	assert.ErrorAs(t, err, &eval.ErrInvalidArguments{})
+}
+
+func TestScope_Structs(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "accessor", src: "(defstruct point x y) (point-y (make-point 1 2))", want: &eval.Integer{Value: 2}},
+		{name: "predicate", src: "(defstruct point x y) (ispoint (make-point 1 2))", want: &eval.Boolean{Value: true}},
+		{name: "predicate of a list", src: "(defstruct point x y) (ispoint '(1 2))", want: &eval.Boolean{Value: false}},
+		{
+			name: "update",
+			src:  "(defstruct point x y) (point-x (point-with (make-point 1 2) 'x 5))",
+			want: &eval.Integer{Value: 5},
+		},
+		{
+			name: "update is persistent",
+			src:  "(defstruct point x y) (setq p (make-point 1 2)) (point-with p 'x 5) (point-x p)",
+			want: &eval.Integer{Value: 1},
+		},
+		{
+			name: "equal",
+			src:  "(defstruct point x y) (equal (make-point 1 2) (make-point 1.0 2))",
+			want: &eval.Boolean{Value: true},
+		},
+		{
+			name: "not equal",
+			src:  "(defstruct point x y) (equal (make-point 1 2) (make-point 2 1))",
+			want: &eval.Boolean{Value: false},
+		},
+		{
+			name: "accessor as a function value",
+			src:  "(defstruct point x y) (map point-x (cons (make-point 1 2) (cons (make-point 3 4) null)))",
+			want: &eval.List{Values: []eval.Expression{&eval.Integer{Value: 1}, &eval.Integer{Value: 3}}},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	res, err := run(t, `(defstruct point x y) (make-point 1 "a")`)
+	require.NoError(t, err)
+	assert.Equal(t, `point{x: 1, y: "a"}`, res.FString())
+
+	_, err = run(t, "(defstruct point x y) (point-with (make-point 1 2) 'z 5)")
+	assert.ErrorIs(t, err, eval.ErrNoField)
+
+	_, err = run(t, "(defstruct point x y) (defstruct size w h) (point-x (make-size 1 2))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	_, err = run(t, "(defstruct point x y) (make-point 1)")
+	assert.ErrorAs(t, err, &eval.ErrInvalidArguments{})
+
+	_, err = run(t, "(defstruct list head rest)")
+	assert.Error(t, err)
+}
+
+func TestScope_Match(t *testing.T) {
+	const shapes = `
+(deftype shape (circle r) (rect w h) blank)
+(func area (s)
+    (match s
+        ((circle r) (times 3 (times r r)))
+        ((rect w h) (times w h))
+        (blank 0)))
+`
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "variant", src: shapes + "(area (rect 2 3))", want: &eval.Integer{Value: 6}},
+		{name: "other variant", src: shapes + "(area (circle 2))", want: &eval.Integer{Value: 12}},
+		{name: "variant without fields", src: shapes + "(area blank)", want: &eval.Integer{Value: 0}},
+		{name: "predicate", src: shapes + "(isshape blank)", want: &eval.Boolean{Value: true}},
+		{name: "predicate of a number", src: shapes + "(isshape 1)", want: &eval.Boolean{Value: false}},
+		{name: "equal", src: shapes + "(equal (circle 1) (circle 1))", want: &eval.Boolean{Value: true}},
+		{name: "not equal", src: shapes + "(equal (circle 1) (circle 2))", want: &eval.Boolean{Value: false}},
+		{name: "literal", src: `(match 2 (1 "one") (2 "two"))`, want: &eval.String{Value: "two"}},
+		{name: "symbol", src: `(match 'b ('a 1) ('b 2))`, want: &eval.Integer{Value: 2}},
+		{name: "wildcard", src: `(match 3 (1 "one") (_ "many"))`, want: &eval.String{Value: "many"}},
+		{name: "variable", src: "(match 3 (1 0) (n (times n 2)))", want: &eval.Integer{Value: 6}},
+		{name: "list", src: "(match '(1 2) ((list a b) (plus a b)))", want: &eval.Integer{Value: 3}},
+		{name: "list of other length", src: "(match '(1 2 3) ((list a b) 0) (_ 1))", want: &eval.Integer{Value: 1}},
+		{name: "empty list", src: "(match null ((list) 0) (_ 1))", want: &eval.Integer{Value: 0}},
+		{
+			name: "nested",
+			src: `
+(deftype expr (num n) (add a b) (mul a b))
+(func calc (e)
+    (match e
+        ((num n) n)
+        ((add (num 0) b) (calc b))
+        ((add a b) (plus (calc a) (calc b)))
+        ((mul a b) (times (calc a) (calc b)))))
+(calc (mul (add (num 0) (num 2)) (add (num 3) (num 4))))`,
+			want: &eval.Integer{Value: 14},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	res, err := run(t, shapes+"(rect 1 (circle 2))")
+	require.NoError(t, err)
+	assert.Equal(t, "(rect 1 (circle 2))", res.FString())
+
+	_, err = run(t, shapes+"(match (circle 1) ((rect w h) 0))")
+	assert.ErrorIs(t, err, eval.ErrNoMatch)
+
+	_, err = run(t, shapes+"(match (circle 1) ((circle a b) 0))")
+	assert.ErrorIs(t, err, eval.ErrInvalidPattern)
+
+	_, err = run(t, "(match '(1 2) ((list a a) 0))")
+	assert.ErrorIs(t, err, eval.ErrInvalidPattern)
+
+	_, err = run(t, "(match 1 ((nothing a) 0))")
+	assert.ErrorIs(t, err, eval.ErrInvalidPattern)
+
+	t.Run("non-exhaustive", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		log.SetOutput(buf)
+		defer log.SetOutput(os.Stderr)
+
+		_, err := run(t, shapes+"(func size (s) (match s ((circle r) r) ((rect 1 h) h))) (size (circle 1)) (size (circle 2))")
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(buf.String(), "match on shape is not exhaustive, missing rect, blank"))
+
+		buf.Reset()
+		_, err = run(t, shapes+"(area (circle 1))")
+		require.NoError(t, err)
+		assert.NotContains(t, buf.String(), "not exhaustive")
+	})
+}
+
+func TestScope_Destructuring(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "cons", src: "(match '(1 2 3) ((cons h t) t))", want: &eval.List{Values: []eval.Expression{&eval.Integer{Value: 2}, &eval.Integer{Value: 3}}}},
+		{name: "cons of empty list", src: "(match '() ((cons h t) h) (_ 0))", want: &eval.Integer{Value: 0}},
+		{name: "nested cons", src: "(match '(1 2 3) ((cons a (cons b _)) (plus a b)))", want: &eval.Integer{Value: 3}},
+		{name: "literal head", src: "(match '(0 5) ((cons 0 (list x)) x) (_ 1))", want: &eval.Integer{Value: 5}},
+		{name: "guard", src: "(match 5 (n when (less n 0) 'neg) (0 'zero) (_ 'pos))", want: &eval.Symbol{Name: "pos"}},
+		{name: "guard sees bindings", src: "(match '(1 2) ((list a b) when (less a b) 'asc) (_ 'desc))", want: &eval.Symbol{Name: "asc"}},
+		{
+			name: "function parameters",
+			src:  "(func sum (l) (match l ('() 0) ((cons h t) (plus h (sum t))))) (sum '(1 2 3))",
+			want: &eval.Integer{Value: 6},
+		},
+		{
+			name: "pattern parameter",
+			src:  "(func first ((cons h _)) h) (first '(7 8))",
+			want: &eval.Integer{Value: 7},
+		},
+		{
+			name: "pattern parameters of a lambda",
+			src:  "(map (lambda ((list k v)) (times k v)) '((1 2) (3 4)))",
+			want: &eval.List{Values: []eval.Expression{&eval.Integer{Value: 2}, &eval.Integer{Value: 12}}},
+		},
+		{name: "let", src: "(let (((cons h t) '(1 2 3)) (n 10)) (plus h n))", want: &eval.Integer{Value: 11}},
+		{name: "let*", src: "(let* (((list a b) '(1 2)) (c (plus a b))) c)", want: &eval.Integer{Value: 3}},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	_, err := run(t, "(func first ((cons h _)) h) (first '())")
+	assert.ErrorIs(t, err, eval.ErrNoMatch)
+
+	_, err = run(t, "(let (((list a b) '(1))) a)")
+	assert.ErrorIs(t, err, eval.ErrNoMatch)
+
+	_, err = run(t, "(match 1 (n when 1 n))")
+	assert.ErrorAs(t, err, &eval.ErrArgumentType{})
+
+	res, err := run(t, "(lambda ((cons h t) n) h)")
+	require.NoError(t, err)
+	assert.Equal(t, "<lambda ((cons h t) n)>", res.FString())
+}
+
+func TestScope_Errors(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+	}{
+		{name: "no error", src: "(try (plus 1 2) (catch e 0))", want: &eval.Integer{Value: 3}},
+		{name: "throw", src: "(try (throw 'oops) (catch e (error-data e)))", want: &eval.Symbol{Name: "oops"}},
+		{name: "thrown kind", src: "(try (throw 'oops) (catch e (error-kind e)))", want: &eval.Symbol{Name: "throw"}},
+		{name: "thrown string", src: `(try (throw "bad") (catch e (error-message e)))`, want: &eval.String{Value: "bad"}},
+		{
+			name: "thrown error",
+			src:  `(try (throw (error 'not-found "no user" 7)) (catch e (error-data e)))`,
+			want: &eval.Integer{Value: 7},
+		},
+		{name: "zero division", src: "(try (divide 1 0) (catch e (error-kind e)))", want: &eval.Symbol{Name: "zero-division"}},
+		{
+			name: "zero division message",
+			src:  "(try (divide 1 0) (catch e (error-message e)))",
+			want: &eval.String{Value: "zero division"},
+		},
+		{name: "argument type", src: `(try (plus 1 "a") (catch e (error-kind e)))`, want: &eval.Symbol{Name: "argument-type"}},
+		{name: "undefined", src: "(try (plus x 1) (catch e (error-data e)))", want: &eval.Symbol{Name: "x"}},
+		{
+			name: "from a nested call",
+			src:  "(func f (x) (divide 1 x)) (func g (x) (plus (f x) 1)) (try (g 0) (catch e (error-kind e)))",
+			want: &eval.Symbol{Name: "zero-division"},
+		},
+		{name: "error?", src: "(try (throw 1) (catch e (error? e)))", want: &eval.Boolean{Value: true}},
+		{name: "error? of a number", src: "(error? 1)", want: &eval.Boolean{Value: false}},
+		{
+			name: "finally",
+			src:  "(setq n 0) (try (divide 1 0) (catch e 1) (finally (setq n 5))) (plus n 0)",
+			want: &eval.Integer{Value: 5},
+		},
+		{
+			name: "finally without error",
+			src:  "(setq n 0) (try 1 (finally (setq n 5))) (plus n 0)",
+			want: &eval.Integer{Value: 5},
+		},
+		{name: "value of the handler", src: "(try (divide 1 0) (catch e 1) (finally 2))", want: &eval.Integer{Value: 1}},
+		{
+			name: "rethrow",
+			src:  "(try (try (throw 'inner) (catch e (throw e))) (catch e (error-data e)))",
+			want: &eval.Symbol{Name: "inner"},
+		},
+		{
+			name: "return from try",
+			src:  "(func f () (prog () ((try (return 1) (catch e 2)) (return 3)))) (f)",
+			want: &eval.Integer{Value: 1},
+		},
+		{
+			name: "return value is caught",
+			src:  "(func f () (prog () ((try (return (divide 1 0)) (catch e (return 2))) (return 3)))) (f)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "break in try",
+			src:  "(setq n 0) (while true (try (setq n (plus n 1)) (cond (equal n 3) (break) null))) (plus n 0)",
+			want: &eval.Integer{Value: 3},
+		},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	_, err := run(t, "(throw 'oops)")
+	var e *eval.Error
+	require.ErrorAs(t, err, &e)
+	assert.Equal(t, "throw", e.Kind)
+
+	_, err = run(t, "(try (divide 1 0) (catch e (throw e)))")
+	assert.ErrorIs(t, err, eval.ErrZeroDivision, "rethrown error keeps its cause")
+
+	_, err = run(t, "(try (divide 1 0) (finally 1))")
+	assert.ErrorIs(t, err, eval.ErrZeroDivision, "error is passed on without catch")
+
+	_, err = runWithLimits(t, "(func f (n) (plus 1 (f n))) (try (f 1) (catch e 0))", eval.Limits{MaxDepth: 100})
+	assert.ErrorAs(t, err, &eval.ErrLimitExceeded{}, "limits can't be caught")
+}
+
+func TestScope_QuotedIdentifiers(t *testing.T) {
+	res, err := run(t, "(quote x)")
+	require.NoError(t, err)
+	assert.Equal(t, "'(x)", res.FString())
+
+	res, err = run(t, "(quote (a b) c)")
+	require.NoError(t, err)
+	assert.Equal(t, "'((a b) c)", res.FString())
+
+	_, err = run(t, "(throw (quote x))")
+	var thrown *eval.Error
+	require.ErrorAs(t, err, &thrown)
+	assert.Equal(t, "'(x)", thrown.Data.FString())
+	assert.Contains(t, err.Error(), "'(x)")
+
+	_, err = run(t, "(func f (l) (divide 1 0)) (f (quote (a b)))")
+	var evalErr *eval.EvalError
+	require.ErrorAs(t, err, &evalErr)
+	require.Len(t, evalErr.Stack, 1)
+	assert.Equal(t, "(f '((a b))) at 1:27", evalErr.Stack[0].String())
+	assert.Contains(t, evalErr.Traceback(), "in (f '((a b))) at 1:27")
+}
+
+func TestScope_Contracts(t *testing.T) {
+	const sqrt = `
+(func isqrt (n)
+    :pre (greatereq n 0)
+    :post (lesseq (times result result) n)
+    (floor (sqrt n)))
+`
+	res, err := run(t, sqrt+"(isqrt 17)")
+	require.NoError(t, err)
+	assert.True(t, (&eval.Integer{Value: 4}).Equal(res), "got %s", res)
+
+	_, err = run(t, sqrt+"(isqrt (minus 0 4))")
+	var contract eval.ErrContract
+	require.ErrorAs(t, err, &contract)
+	assert.Equal(t, eval.ErrContract{
+		Kind:      "precondition",
+		Function:  "isqrt",
+		Condition: "(greatereq n 0)",
+		Values:    []string{"n = -4"},
+	}, contract)
+
+	_, err = run(t, "(func bad (x) :post (greater result x) (minus x 1)) (bad 5)")
+	require.ErrorAs(t, err, &contract)
+	assert.Equal(t, "postcondition (greater result x) of bad failed: x = 5, result = 4", contract.Error())
+
+	_, err = run(t, `(assert (equal 1 2) "numbers differ")`)
+	require.ErrorAs(t, err, &contract)
+	assert.Equal(t, "assertion (equal 1 2) failed: numbers differ", contract.Error())
+
+	res, err = run(t, "(assert (equal 1 1))")
+	require.NoError(t, err)
+	assert.Equal(t, eval.Null{}, res)
+
+	res, err = run(t, `(try (assert false) (catch e (error-kind e)))`)
+	require.NoError(t, err)
+	assert.True(t, (&eval.Symbol{Name: "contract"}).Equal(res))
+
+	t.Run("off", func(t *testing.T) {
+		scope := eval.NewScope("", nil, false)
+		scope.SetContracts(false)
+		p := parser.NewParser(lexer.NewLexer(strings.NewReader(
+			"(func bad (x) :pre false :post false x) (assert false) (bad 1)")))
+		var res eval.Expression
+		for {
+			expr, err := p.ParseNext()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			res, err = scope.Eval(expr)
+			require.NoError(t, err)
+		}
+		assert.True(t, (&eval.Integer{Value: 1}).Equal(res))
+	})
+
+	t.Run("tail calls without postconditions", func(t *testing.T) {
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+		res, err := runWithLimits(t, "(func count (n) :pre (greatereq n 0) (cond (equal n 0) 0 (count (minus n 1)))) (count 10000)",
+			eval.Limits{MaxDepth: 100})
+		require.NoError(t, err)
+		assert.True(t, (&eval.Integer{Value: 0}).Equal(res))
+	})
+}
+
+func TestScope_Traceback(t *testing.T) {
+	const src = `
+(func ratio (a b)
+    (divide a b))
+(func report (a b) (plus 1 (ratio a b)))
+(func countdown (n) (cond (equal n 0) (report 1 0) (countdown (minus n 1))))
+`
+
+	_, err := run(t, src+"(countdown 3)")
+	var evalErr *eval.EvalError
+	require.ErrorAs(t, err, &evalErr)
+	assert.ErrorIs(t, err, eval.ErrZeroDivision)
+	assert.Equal(t, "divide", evalErr.Call)
+	assert.Equal(t, eval.Position{Line: 3, Col: 5}, evalErr.Pos)
+
+	// the call of countdown is replaced by the ones in its tail position
+	require.Len(t, evalErr.Stack, 2)
+	assert.Equal(t, "(report 1 0) at 5:39", evalErr.Stack[0].String())
+	assert.Equal(t, "(ratio 1 0) at 4:28", evalErr.Stack[1].String())
+	assert.Equal(t, `call "divide" at 3:5: zero division
+    in (ratio 1 0) at 4:28
+    in (report 1 0) at 5:39`, evalErr.Traceback())
+
+	t.Run("thrown again", func(t *testing.T) {
+		_, err := run(t, src+"(func rethrow () (try (ratio 1 0) (catch e (throw e))))\n(rethrow)")
+		require.ErrorAs(t, err, &evalErr)
+		assert.ErrorIs(t, err, eval.ErrZeroDivision)
+		assert.Equal(t, "throw", evalErr.Call)
+		assert.Equal(t, eval.Position{Line: 6, Col: 44}, evalErr.Pos)
+		require.Len(t, evalErr.Stack, 1)
+		assert.Equal(t, "rethrow", evalErr.Stack[0].Function)
+	})
+
+	t.Run("higher-order call", func(t *testing.T) {
+		_, err := run(t, "(setq xs '(1 0))\n(map (lambda (x) (divide 1 x)) xs)")
+		require.ErrorAs(t, err, &evalErr)
+		require.Len(t, evalErr.Stack, 1)
+		assert.Equal(t, "(lambda 0) at 2:1", evalErr.Stack[0].String())
+	})
+
+	t.Run("recursion", func(t *testing.T) {
+		_, err := runWithLimits(t, "(func f (n) (plus 1 (f (minus n 1))))\n(f 1000)", eval.Limits{MaxDepth: 200})
+		require.ErrorAs(t, err, &evalErr)
+		require.Len(t, evalErr.Stack, 200)
+		assert.Equal(t, `call "f" at 1:21: depth limit of 200 exceeded
+    in (f 801) at 1:21
+    ... repeated 198 more times
+    in (f 1000) at 2:1`, evalErr.Traceback())
+	})
+
+	t.Run("mutual recursion", func(t *testing.T) {
+		_, err := runWithLimits(t, `(func f (n) (plus 1 (g (minus n 1))))
+(func g (n) (plus 1 (f n)))
+(f 1000)`, eval.Limits{MaxDepth: 200})
+		require.ErrorAs(t, err, &evalErr)
+		lines := strings.Split(evalErr.Traceback(), "\n")
+		require.Len(t, lines, 22)
+		assert.Equal(t, "    in (g 900) at 1:21", lines[1])
+		assert.Equal(t, "    ... 180 more calls", lines[16])
+		assert.Equal(t, "    in (f 1000) at 3:1", lines[21])
+	})
+}
+
+func TestScope_ErrorCatalog(t *testing.T) {
+	t.Run("argument type", func(t *testing.T) {
+		_, err := run(t, "(plus 1 '(2))")
+		var typeErr eval.ErrArgumentType
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, eval.ErrArgumentType{Function: "plus", Arg: 2, Expected: "number", Actual: "list"}, typeErr)
+		assert.Equal(t, `call "plus" at 1:1: argument 2: expected argument of type number, got list`, err.Error())
+	})
+
+	t.Run("argument type of a variadic function", func(t *testing.T) {
+		_, err := run(t, "(and true 1)")
+		var typeErr eval.ErrArgumentType
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, eval.ErrArgumentType{Function: "and", Arg: 2, Expected: "boolean", Actual: "number"}, typeErr)
+	})
+
+	t.Run("arity of a builtin", func(t *testing.T) {
+		_, err := run(t, "(head '(1) '(2))")
+		var argsErr eval.ErrInvalidArguments
+		require.ErrorAs(t, err, &argsErr)
+		assert.Equal(t, eval.ErrInvalidArguments{Function: "head", Expected: "1", Actual: 2}, argsErr)
+	})
+
+	t.Run("arity of a function", func(t *testing.T) {
+		_, err := run(t, "(func f (x) x) (f 1 2)")
+		var argsErr eval.ErrInvalidArguments
+		require.ErrorAs(t, err, &argsErr)
+		assert.Equal(t, eval.ErrInvalidArguments{Function: "f", Expected: "1", Actual: 2}, argsErr)
+	})
+
+	t.Run("nested call keeps its function", func(t *testing.T) {
+		_, err := run(t, "(plus 1 (minus 1 '(2)))")
+		var typeErr eval.ErrArgumentType
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, "minus", typeErr.Function)
+	})
+
+	t.Run("argument type of a function value", func(t *testing.T) {
+		_, err := run(t, "(map head '(1))")
+		var typeErr eval.ErrArgumentType
+		require.ErrorAs(t, err, &typeErr)
+		assert.Equal(t, "head", typeErr.Function)
+	})
+
+	nameTbl := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{
+			name: "undefined argument of a builtin",
+			src:  "(plus x 1)",
+			want: &eval.ErrUndefined{Name: "x", Function: "plus", Arg: 1},
+		},
+		{
+			name: "undefined argument of a function",
+			src:  "(func f (a b) a) (f 1 y)",
+			want: &eval.ErrUndefined{Name: "y", Function: "f", Arg: 2},
+		},
+		{
+			name: "undefined in a nested call",
+			src:  "(plus 1 (minus (times y 2) 1))",
+			want: &eval.ErrUndefined{Name: "y", Function: "times", Arg: 1},
+		},
+		{
+			name: "undefined deeper in the arguments",
+			src:  "(let ((a z)) a)",
+			want: &eval.ErrUndefined{Name: "z", Function: "let"},
+		},
+		{
+			name: "constant",
+			src:  "(const c 1) (setq c 2)",
+			want: &eval.ErrConstant{Name: "c", Function: "setq", Arg: 1},
+		},
+		{
+			name: "defined",
+			src:  "(define d 1) (define d 2)",
+			want: &eval.ErrDefined{Name: "d", Function: "define", Arg: 1},
+		},
+		{
+			name: "reserved",
+			src:  "(set! plus 1)",
+			want: &eval.ErrReserved{Name: "plus", Function: "set!", Arg: 1},
+		},
+	}
+
+	for _, tt := range nameTbl {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, tt.src)
+			require.Error(t, err)
+			got := reflect.New(reflect.TypeOf(tt.want).Elem()).Interface()
+			require.True(t, errors.As(err, got), err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	tbl := []struct {
+		src  string
+		code string
+	}{
+		{src: "(divide 1 0)", code: "zero-division"},
+		{src: "(head '())", code: "empty-list"},
+		{src: "(plus x 1)", code: "undefined"},
+		{src: "(plus 1 '(2))", code: "argument-type"},
+		{src: "(throw 'oops)", code: "throw"},
+		{src: "(throw (error 'not-found \"no such key\"))", code: "not-found"},
+		{src: "(assert false)", code: "contract"},
+		{src: "(func f (n) (f n)) (f 1)", code: "limit"},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := runWithLimits(t, tt.src, eval.Limits{MaxDepth: 10, MaxSteps: 1000})
+			require.Error(t, err)
+			assert.Equal(t, tt.code, eval.ErrorCode(err))
+
+			var evalErr *eval.EvalError
+			require.ErrorAs(t, err, &evalErr)
+			assert.Equal(t, tt.code, evalErr.Code())
+		})
+	}
+}
+
+func runIn(t *testing.T, scope *eval.Scope, src string) (eval.Expression, error) {
+	t.Helper()
+
+	p := parser.NewParser(lexer.NewLexer(strings.NewReader(src)))
+	var result eval.Expression = eval.Null{}
+	for {
+		expr, err := p.ParseNext()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		require.NoError(t, err)
+
+		if result, err = scope.Eval(expr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func TestScope_Declarations(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+		err  error
+	}{
+		{name: "define", src: "(define x 1) (plus x 0)", want: &eval.Integer{Value: 1}},
+		{name: "define twice", src: "(define x 1) (define x 2)", err: eval.ErrDefined{Name: "x"}},
+		{name: "define a parameter", src: "(func f (n) (define n 2)) (f 1)", err: eval.ErrDefined{Name: "n"}},
+		{name: "define a special form", src: "(define let 1)", err: eval.ErrReserved{Name: "let"}},
+		{
+			name: "define in let is local to it",
+			src:  "(define x 1) (setq y (let ((z 2)) (define x 5) (plus x z))) (plus x y)",
+			want: &eval.Integer{Value: 8},
+		},
+		{name: "setq of a defined variable", src: "(define x 1) (setq x 2) (plus x 0)", want: &eval.Integer{Value: 2}},
+		{name: "const", src: "(const k 3) (plus k 0)", want: &eval.Integer{Value: 3}},
+		{name: "setq of a constant", src: "(const k 3) (setq k 4)", err: eval.ErrConstant{Name: "k"}},
+		{name: "func of a constant", src: "(const k 3) (func k () 1)", err: eval.ErrConstant{Name: "k"}},
+		{name: "const twice", src: "(const k 3) (const k 4)", err: eval.ErrDefined{Name: "k"}},
+		{name: "let shadows a constant", src: "(const k 3) (let ((k 1)) (setq k 2) (plus k 0))", want: &eval.Integer{Value: 2}},
+		{
+			name: "setq in a function binds a local variable",
+			src:  "(const k 3) (func f () (prog () ((setq k 1) (return k)))) (plus (f) k)",
+			want: &eval.Integer{Value: 4},
+		},
+		{
+			name: "caught",
+			src:  "(const k 3) (try (setq k 4) (catch e (error-kind e)))",
+			want: &eval.Symbol{Name: "constant"},
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		strictTbl := []struct {
+			name string
+			src  string
+			want eval.Expression
+		}{
+			{name: "setq of a defined variable", src: "(define x 1) (setq x 2) (plus x 0)", want: &eval.Integer{Value: 2}},
+			{
+				name: "setq in a loop",
+				src:  "(define i 0) (while (less i 3) (setq i (plus i 1))) (plus i 0)",
+				want: &eval.Integer{Value: 3},
+			},
+			{name: "setq of a parameter", src: "(func f (n) (let () (setq n (plus n 1)) n)) (f 1)", want: &eval.Integer{Value: 2}},
+			{name: "setq of an undefined variable", src: "(setq x 1)"},
+			{name: "setq of a variable of the enclosing function", src: "(define x 1) (func f () (setq x 2)) (f)"},
+		}
+
+		for _, tt := range strictTbl {
+			t.Run(tt.name, func(t *testing.T) {
+				scope := eval.NewScope("", nil, false)
+				scope.SetStrict(true)
+				res, err := runIn(t, scope, tt.src)
+				if tt.want == nil {
+					assert.ErrorIs(t, err, eval.ErrUndefined{Name: "x"})
+					return
+				}
+				require.NoError(t, err)
+				assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+			})
+		}
+	})
+}
+
+func TestScope_Set(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+		err  error
+	}{
+		{name: "top level", src: "(setq x 1) (set! x 2) (plus x 0)", want: &eval.Integer{Value: 2}},
+		{name: "undefined", src: "(set! x 1)", err: eval.ErrUndefined{Name: "x"}},
+		{name: "builtin", src: "(set! plus 1)", err: eval.ErrReserved{Name: "plus"}},
+		{name: "constant", src: "(const k 1) (set! k 2)", err: eval.ErrConstant{Name: "k"}},
+		{
+			name: "from a function",
+			src:  "(setq x 1) (func inc () (set! x (plus x 1))) (inc) (inc) (plus x 0)",
+			want: &eval.Integer{Value: 3},
+		},
+		{
+			name: "in prog, the variable not exposed",
+			src:  "(setq x 1) (prog () ((set! x (plus x 1)))) (plus x 0)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "in prog, the copy of the exposed variable",
+			src:  "(setq x 1) (prog (x) ((set! x (plus x 1)))) (plus x 0)",
+			want: &eval.Integer{Value: 1},
+		},
+		{
+			name: "the nearest binding",
+			src:  "(setq x 1) (setq y (let ((x 10)) (set! x 20) x)) (plus x y)",
+			want: &eval.Integer{Value: 21},
+		},
+		{
+			name: "captured by a closure",
+			src: `(func counter () (let ((n 0)) (lambda () (let () (set! n (plus n 1)) n))))
+(setq c (counter))
+(c)
+(c)`,
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "a parameter doesn't change the caller",
+			src:  "(setq x 1) (func f (x) (let () (set! x 5) x)) (plus (f x) x)",
+			want: &eval.Integer{Value: 6},
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
+
+func TestScope_Resolution(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want eval.Expression
+		err  error
+	}{
+		{
+			name: "recursion",
+			src:  "(func fib (n) (cond ((less n 2) n) (else (plus (fib (minus n 1)) (fib (minus n 2)))))) (fib 10)",
+			want: &eval.Integer{Value: 55},
+		},
+		{
+			name: "captured by a closure",
+			src:  "(func adder (n) (lambda (x) (plus x n))) (funcall (adder 2) 3)",
+			want: &eval.Integer{Value: 5},
+		},
+		{
+			name: "captured two functions up",
+			src:  "(func f (a) (lambda (b) (lambda (c) (plus a (times b c))))) (funcall (funcall (f 1) 2) 3)",
+			want: &eval.Integer{Value: 7},
+		},
+		{
+			name: "shadowed by let",
+			src:  "(func f (x) (plus x (let ((x 10)) x))) (f 1)",
+			want: &eval.Integer{Value: 11},
+		},
+		{
+			name: "shadowed by the parameter of a lambda",
+			src:  "(func f (x) (funcall (lambda (x) (times x 2)) (plus x 1))) (f 1)",
+			want: &eval.Integer{Value: 4},
+		},
+		{
+			name: "setq of a parameter",
+			src:  "(func f (x) (let () (setq x (plus x 1)) x)) (f 1)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "set! of a captured parameter",
+			src: `(func counter (n) (lambda () (let () (set! n (plus n 1)) n)))
+(setq c (counter 10))
+(c)
+(c)`,
+			want: &eval.Integer{Value: 12},
+		},
+		{
+			name: "the variable of a pattern",
+			src:  "(func f (x) (match '(1 2) ((cons x _) x))) (f 5)",
+			want: &eval.Integer{Value: 1},
+		},
+		{
+			name: "the same name twice",
+			src:  "(func f (x x) x) (f 1 2)",
+			want: &eval.Integer{Value: 2},
+		},
+		{
+			name: "a parameter called as a function",
+			src:  "(func twice (g x) (g (g x))) (func inc (x) (plus x 1)) (twice inc 1)",
+			want: &eval.Integer{Value: 3},
+		},
+		{
+			name: "define of a parameter",
+			src:  "(func f (x) (define x 2)) (f 1)",
+			err:  eval.ErrDefined{Name: "x"},
+		},
+		{
+			name: "shadowed by a record function",
+			src:  "(func f (point-x) (lambda () (let () (defstruct point x) (funcall point-x (make-point 2))))) (funcall (f 1))",
+			want: &eval.Integer{Value: 2},
+		},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := run(t, tt.src)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+}
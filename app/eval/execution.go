@@ -0,0 +1,126 @@
+package eval
+
+import (
+	"context"
+	"math/rand"
+)
+
+// Limits restricts the resources an evaluation may take. A zero value of
+// any field means there is no such limit.
+type Limits struct {
+	// MaxDepth is the number of nested function calls, calls in tail
+	// positions don't add to it.
+	MaxDepth int
+	// MaxSteps is the number of expressions evaluated by the program.
+	MaxSteps int
+	// MaxValues approximates the memory ceiling, it is the number of values
+	// allocated by the program, i.e. the results of calls other than null,
+	// the elements of constructed lists and the variables bound by function
+	// calls.
+	MaxValues int
+}
+
+// execution is the state of the program shared by all of its scopes: the
+// context of the evaluation, the resources taken by it, the calls in
+// progress and the random number generator.
+type execution struct {
+	ctx    context.Context
+	rnd    *rand.Rand
+	limits Limits
+	depth  int
+	steps  int
+	values int
+	warned map[*Call]bool  // the matches reported as not exhaustive
+	shadow map[string]bool // the builtin functions redefined by the program
+	stack  []Frame         // the calls of the functions in progress
+	pos    Position        // the place of the innermost call in progress
+
+	noContracts bool
+	strict      bool // setq may only update the variables bound already
+}
+
+// EvalContext evaluates the expression as Eval, but stops at the next call
+// or loop iteration, once the context is done, and returns the error of the
+// context wrapped with the calls in progress.
+func (s *Scope) EvalContext(ctx context.Context, expr Expression) (Expression, error) {
+	prev := s.exec.ctx
+	s.exec.ctx = ctx
+	defer func() { s.exec.ctx = prev }()
+
+	return s.Eval(expr)
+}
+
+// done returns the error of the context, if it is done.
+func (e *execution) done() error {
+	if e.ctx == nil {
+		return nil
+	}
+	select {
+	case <-e.ctx.Done():
+		return e.ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// SetLimits sets the limits for the whole program the scope belongs to,
+// the steps and values are counted anew from here on, so that whatever is
+// evaluated before, e.g. the prelude, doesn't take from the budget.
+func (s *Scope) SetLimits(limits Limits) {
+	s.exec.limits = limits
+	s.exec.steps, s.exec.values = 0, 0
+}
+
+// SetStrict turns on or off the strict mode for the whole program the
+// scope belongs to. In the strict mode setq doesn't bind new variables,
+// they are declared with define or const.
+func (s *Scope) SetStrict(on bool) {
+	s.exec.strict = on
+}
+
+// enter puts the call of the function on the stack.
+func (e *execution) enter(frame *Frame) error {
+	if e.limits.MaxDepth > 0 && e.depth >= e.limits.MaxDepth {
+		return ErrLimitExceeded{Limit: "depth", Max: e.limits.MaxDepth}
+	}
+	e.depth++
+	e.stack = append(e.stack, *frame)
+	return nil
+}
+
+// replace replaces the innermost call on the stack with the one in its
+// tail position.
+func (e *execution) replace(frame *Frame) { e.stack[len(e.stack)-1] = *frame }
+
+func (e *execution) leave() {
+	e.depth--
+	e.stack = e.stack[:len(e.stack)-1]
+}
+
+// redefine notes the name bound by the program, if it is the one of a
+// builtin function, so that its calls look the name up in scopes first.
+func (e *execution) redefine(name string) {
+	if _, ok := builtinMethods[name]; !ok || specialForms[name] {
+		return
+	}
+	if e.shadow == nil {
+		e.shadow = map[string]bool{}
+	}
+	e.shadow[name] = true
+}
+
+func (e *execution) step() error {
+	e.steps++
+	if e.limits.MaxSteps > 0 && e.steps > e.limits.MaxSteps {
+		return ErrLimitExceeded{Limit: "steps", Max: e.limits.MaxSteps}
+	}
+	return nil
+}
+
+func (e *execution) alloc(n int) error {
+	e.values += n
+	if e.limits.MaxValues > 0 && e.values > e.limits.MaxValues {
+		return ErrLimitExceeded{Limit: "values", Max: e.limits.MaxValues}
+	}
+	return nil
+}
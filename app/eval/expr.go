@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"math"
 )
 
 // Expression describes any language expression.
@@ -19,6 +20,9 @@ type Expression interface {
 type Call struct {
 	Name string
 	Args []Expression
+	Pos  Position
+
+	resolved bool // the references to parameters in the body are resolved
 }
 
 // FString returns the F language representation of the call.
@@ -48,6 +52,24 @@ func (*Call) Equal(Expression) bool {
 	return false
 }
 
+// sourceString returns the representation of the expression as it is
+// written, e.g. (cons h t).
+func sourceString(expr Expression) string {
+	switch expr := expr.(type) {
+	case *Identifier:
+		return expr.Name
+	case *Local:
+		return expr.Name
+	case *Call:
+		args := []string{expr.Name}
+		for _, arg := range expr.Args {
+			args = append(args, sourceString(arg))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(args, " "))
+	}
+	return expr.FString()
+}
+
 // Identifier represents an identifier.
 type Identifier struct{ Name string }
 
@@ -82,12 +104,31 @@ func (l *List) String() string {
 }
 
 // FString returns the F language representation of the list.
-func (l *List) FString() string {
-	var args []string
-	for _, arg := range l.Values {
-		args = append(args, arg.String())
+func (l *List) FString() string { return "'" + quoted(l) }
+
+// quoted returns the representation of the value inside a quoted list,
+// where nested lists and symbols need no quote sign. The identifiers and
+// calls, which quote leaves unevaluated, are printed as they are written.
+func quoted(e Expression) string {
+	switch e := e.(type) {
+	case *List:
+		args := make([]string, len(e.Values))
+		for idx, arg := range e.Values {
+			args[idx] = quoted(arg)
+		}
+		return fmt.Sprintf("(%s)", strings.Join(args, " "))
+	case *Call:
+		args := []string{e.Name}
+		for _, arg := range e.Args {
+			args = append(args, quoted(arg))
+		}
+		return fmt.Sprintf("(%s)", strings.Join(args, " "))
+	case *Symbol:
+		return e.Name
+	case *Identifier, *Local:
+		return sourceString(e)
 	}
-	return fmt.Sprintf("'(%s)", strings.Join(args, " "))
+	return e.FString()
 }
 
 // Equal returns true if the two lists are equal.
@@ -106,23 +147,25 @@ func (l *List) Equal(b Expression) bool {
 	return false
 }
 
-// Number represents a number.
+// Number represents an inexact floating point number.
 type Number struct{ Value float64 }
 
 // Type returns the type of the number.
 func (n *Number) Type() string { return "number" }
 
-// String returns the string representation of the number.
-func (n *Number) String() string { return strconv.FormatFloat(n.Value, 'f', -1, 64) }
-
-// Equal returns true if the two numbers are equal.
-func (n *Number) Equal(b Expression) bool {
-	if b, ok := b.(*Number); ok {
-		return n.Value == b.Value
+// String returns the string representation of the number, it always has
+// a fractional part to tell it from an integer, e.g. 2.0.
+func (n *Number) String() string {
+	s := strconv.FormatFloat(n.Value, 'f', -1, 64)
+	if math.IsInf(n.Value, 0) || math.IsNaN(n.Value) || strings.Contains(s, ".") {
+		return s
 	}
-	return false
+	return s + ".0"
 }
 
+// Equal returns true if the number is numerically equal to the float.
+func (n *Number) Equal(b Expression) bool { return equalNumbers(n, b) }
+
 // FString returns the F language representation of the number.
 func (n *Number) FString() string { return n.String() }
 
@@ -146,6 +189,42 @@ func (b *Boolean) Equal(b2 Expression) bool {
 // FString returns the F language representation of the boolean.
 func (b *Boolean) FString() string { return b.String() }
 
+// String represents a string.
+type String struct{ Value string }
+
+// Type returns the type of the string.
+func (s *String) Type() string { return "string" }
+
+// String returns the string representation of the string, which is quoted.
+func (s *String) String() string { return strconv.Quote(s.Value) }
+
+// Equal returns true if the two strings are equal.
+func (s *String) Equal(e Expression) bool {
+	s2, ok := e.(*String)
+	return ok && s.Value == s2.Value
+}
+
+// FString returns the F language representation of the string.
+func (s *String) FString() string { return s.String() }
+
+// Symbol represents a quoted name, e.g. 'red, which evaluates to itself.
+type Symbol struct{ Name string }
+
+// Type returns the type of the symbol.
+func (s *Symbol) Type() string { return "symbol" }
+
+// String returns the string representation of the symbol.
+func (s *Symbol) String() string { return s.Name }
+
+// Equal returns true if the two symbols have the same name.
+func (s *Symbol) Equal(e Expression) bool {
+	s2, ok := e.(*Symbol)
+	return ok && s.Name == s2.Name
+}
+
+// FString returns the F language representation of the symbol.
+func (s *Symbol) FString() string { return "'" + s.Name }
+
 // Null represents a null value.
 type Null struct{}
 
@@ -165,9 +244,131 @@ func (n Null) Equal(e Expression) bool {
 // FString returns the F language representation of the null.
 func (n Null) FString() string { return "null" }
 
+// Closure represents a function along with the scope it was defined in.
+type Closure struct {
+	Name     string // empty for lambdas
+	ArgNames []string
+	Params   []Expression // the patterns of the arguments, nil if all are names
+	Body     Expression
+	Scope    *Scope
+
+	Pre  []Expression // the conditions checked before the call
+	Post []Expression // the conditions checked after the call, with result bound
+
+	index map[string]int // the positions of ArgNames, built on the first call
+}
+
+// slotIndex returns the positions of the arguments by their names, shared
+// by all calls of the closure. The last one of the same names wins, as it
+// did when the arguments were bound one by one.
+func (c *Closure) slotIndex() map[string]int {
+	if c.index == nil {
+		c.index = make(map[string]int, len(c.ArgNames))
+		for idx, name := range c.ArgNames {
+			c.index[name] = idx
+		}
+	}
+	return c.index
+}
+
+// Type returns the type of the closure.
+func (c *Closure) Type() string { return "function" }
+
+// String returns the string representation of the closure.
+func (c *Closure) String() string {
+	return fmt.Sprintf("%s([%s], %s)", c.name(), strings.Join(c.ArgNames, ", "), c.Body)
+}
+
+// FString returns the F language representation of the closure.
+func (c *Closure) FString() string {
+	if c.Name == "" {
+		return fmt.Sprintf("<lambda (%s)>", strings.Join(c.ArgNames, " "))
+	}
+	return fmt.Sprintf("<function %s (%s)>", c.Name, strings.Join(c.ArgNames, " "))
+}
+
+// Equal returns true if both are the same closure.
+func (c *Closure) Equal(e Expression) bool {
+	c2, ok := e.(*Closure)
+	return ok && c == c2
+}
+
+func (c *Closure) name() string {
+	if c.Name == "" {
+		return "lambda"
+	}
+	return c.Name
+}
+
+// Builtin represents a builtin function as a value.
+type Builtin struct{ Name string }
+
+// Type returns the type of the builtin.
+func (b *Builtin) Type() string { return "function" }
+
+// String returns the string representation of the builtin.
+func (b *Builtin) String() string { return b.Name }
+
+// FString returns the F language representation of the builtin.
+func (b *Builtin) FString() string { return fmt.Sprintf("<builtin %s>", b.Name) }
+
+// Equal returns true if both are the same builtin.
+func (b *Builtin) Equal(e Expression) bool {
+	b2, ok := e.(*Builtin)
+	return ok && b.Name == b2.Name
+}
+
+// Native represents a function value implemented in Go, such as the
+// constructor of a struct. Unlike builtins, it gets evaluated arguments.
+type Native struct {
+	Name  string
+	Arity int // -1 for any number of arguments
+	Fn    func(s *Scope, args []Expression) (Expression, error)
+
+	variant *VariantDef // set for the constructors of variants
+}
+
+// Type returns the type of the native function.
+func (n *Native) Type() string { return "function" }
+
+// String returns the string representation of the native function.
+func (n *Native) String() string { return n.Name }
+
+// FString returns the F language representation of the native function.
+func (n *Native) FString() string { return fmt.Sprintf("<function %s>", n.Name) }
+
+// Equal returns true if both are the same native function.
+func (n *Native) Equal(e Expression) bool {
+	n2, ok := e.(*Native)
+	return ok && n == n2
+}
+
 type brk struct{}
 
 func (b brk) FString() string         { panic("must never be called") }
 func (b brk) String() string          { panic("must never be called") }
 func (b brk) Type() string            { panic("must never be called") }
 func (b brk) Equal(e Expression) bool { panic("must never be called") }
+
+type cont struct{}
+
+func (c cont) FString() string         { panic("must never be called") }
+func (c cont) String() string          { panic("must never be called") }
+func (c cont) Type() string            { panic("must never be called") }
+func (c cont) Equal(e Expression) bool { panic("must never be called") }
+
+// tailCall is an expression in a tail position, which is left to the loop
+// of Scope.Eval to evaluate in the given scope, so that the tail calls
+// don't grow the Go stack. ret marks the value of a return, frame marks
+// the body of a called function.
+type tailCall struct {
+	expr  Expression
+	scope *Scope
+	ret   bool
+	frame *Frame
+}
+
+func (t *tailCall) FString() string         { panic("must never be called") }
+func (t *tailCall) String() string          { panic("must never be called") }
+func (t *tailCall) Type() string            { panic("must never be called") }
+func (t *tailCall) Equal(e Expression) bool { panic("must never be called") }
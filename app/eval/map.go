@@ -0,0 +1,309 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Map represents an immutable hash map, which keeps its keys in the order
+// they were first put. Keys are numbers, booleans, strings and symbols,
+// compared by value, so that 1 and 1.0 are the same key.
+//
+// Changing the map makes a new one, the original one stays the same. The
+// map is a hash trie, so the new map shares all of the original one but
+// the path to the changed key, and a put or a delete takes O(log n) time
+// and memory, not O(n).
+type Map struct {
+	root *mapNode
+	size int
+	next int // the order of the next new key
+}
+
+// mapNode is the node of the hash trie: either a branch, which children are
+// picked by the next bits of the hash, or a leaf with the entries, which
+// hashes are equal up to the depth of the leaf. A leaf has a single entry,
+// unless it is at the full depth, where the hashes of its entries collide.
+type mapNode struct {
+	children *[mapWidth]*mapNode
+	entries  []mapEntry
+}
+
+type mapEntry struct {
+	hash  string // the hash of the key, see hashKey
+	bits  uint64 // the hash of the hash to find the place in the trie
+	order int    // the order the key was first put in
+	key   Expression
+	value Expression
+}
+
+const (
+	mapBits  = 5
+	mapWidth = 1 << mapBits
+	mapDepth = 64 / mapBits // beyond it the bits of the hash are over
+)
+
+// NewMap returns the map of the keys to the values at the same positions,
+// the later of the equal keys wins.
+func NewMap(keys, values []Expression) (*Map, error) {
+	m := &Map{}
+	for idx, key := range keys {
+		var err error
+		if m, err = m.Put(key, values[idx]); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Type returns the type of the map.
+func (m *Map) Type() string { return "map" }
+
+// String returns the string representation of the map.
+func (m *Map) String() string {
+	entries := m.entries()
+	pairs := make([]string, len(entries))
+	for idx, e := range entries {
+		pairs[idx] = fmt.Sprintf("%s: %s", e.key, e.value)
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
+}
+
+// FString returns the F language representation of the map.
+func (m *Map) FString() string {
+	args := []string{"hashmap"}
+	for _, e := range m.entries() {
+		args = append(args, e.key.FString(), e.value.FString())
+	}
+	return fmt.Sprintf("(%s)", strings.Join(args, " "))
+}
+
+// Equal returns true if both maps have equal values for the same keys, in
+// whatever order.
+func (m *Map) Equal(e Expression) bool {
+	m2, ok := e.(*Map)
+	if !ok || m.Len() != m2.Len() {
+		return false
+	}
+	equal := true
+	m.root.walk(func(e mapEntry) {
+		e2, ok := m2.root.get(e.hash, e.bits)
+		equal = equal && ok && e.value.Equal(e2.value)
+	})
+	return equal
+}
+
+// Len returns the number of the keys.
+func (m *Map) Len() int { return m.size }
+
+// Get returns the value of the key.
+func (m *Map) Get(key Expression) (Expression, bool, error) {
+	hash, err := hashKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	e, ok := m.root.get(hash, hashBits(hash))
+	if !ok {
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Put returns the map, where the key has the value.
+func (m *Map) Put(key, value Expression) (*Map, error) {
+	hash, err := hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	e := mapEntry{hash: hash, bits: hashBits(hash), order: m.next, key: key, value: value}
+	root, added := m.root.put(e, 0)
+	if !added {
+		return &Map{root: root, size: m.size, next: m.next}, nil
+	}
+	return &Map{root: root, size: m.size + 1, next: m.next + 1}, nil
+}
+
+// Delete returns the map without the key.
+func (m *Map) Delete(key Expression) (*Map, error) {
+	hash, err := hashKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	root, removed := m.root.delete(hash, hashBits(hash), 0)
+	if !removed {
+		return m, nil
+	}
+	return &Map{root: root, size: m.size - 1, next: m.next}, nil
+}
+
+// Keys returns the keys in the order they were put.
+func (m *Map) Keys() []Expression {
+	entries := m.entries()
+	keys := make([]Expression, len(entries))
+	for idx, e := range entries {
+		keys[idx] = e.key
+	}
+	return keys
+}
+
+// Values returns the values in the order of their keys.
+func (m *Map) Values() []Expression {
+	entries := m.entries()
+	values := make([]Expression, len(entries))
+	for idx, e := range entries {
+		values[idx] = e.value
+	}
+	return values
+}
+
+// entries returns the entries in the order their keys were put.
+func (m *Map) entries() []mapEntry {
+	res := make([]mapEntry, 0, m.size)
+	m.root.walk(func(e mapEntry) { res = append(res, e) })
+	sort.Slice(res, func(i, j int) bool { return res[i].order < res[j].order })
+	return res
+}
+
+func (n *mapNode) get(hash string, bits uint64) (mapEntry, bool) {
+	for depth := 0; n != nil; depth++ {
+		if n.children == nil {
+			for _, e := range n.entries {
+				if e.hash == hash {
+					return e, true
+				}
+			}
+			return mapEntry{}, false
+		}
+		n = n.children[mapSlot(bits, depth)]
+	}
+	return mapEntry{}, false
+}
+
+// put returns the node with the entry put, the node itself is left intact.
+// An existing key keeps its order. added reports whether the key is new.
+func (n *mapNode) put(e mapEntry, depth int) (res *mapNode, added bool) {
+	if n == nil {
+		return &mapNode{entries: []mapEntry{e}}, true
+	}
+
+	if n.children != nil {
+		children := *n.children
+		idx := mapSlot(e.bits, depth)
+		children[idx], added = children[idx].put(e, depth+1)
+		return &mapNode{children: &children}, added
+	}
+
+	for idx, old := range n.entries {
+		if old.hash == e.hash {
+			e.order = old.order
+			entries := append([]mapEntry(nil), n.entries...)
+			entries[idx] = e
+			return &mapNode{entries: entries}, false
+		}
+	}
+
+	if depth >= mapDepth {
+		return &mapNode{entries: append(append([]mapEntry(nil), n.entries...), e)}, true
+	}
+
+	// the leaf turns into a branch with the old entry and the new one
+	branch := &mapNode{children: &[mapWidth]*mapNode{}}
+	for _, old := range n.entries {
+		branch, _ = branch.put(old, depth)
+	}
+	return branch.put(e, depth)
+}
+
+// delete returns the node without the entry of the hash, the node itself
+// is left intact.
+func (n *mapNode) delete(hash string, bits uint64, depth int) (res *mapNode, removed bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	if n.children != nil {
+		idx := mapSlot(bits, depth)
+		child, removed := n.children[idx].delete(hash, bits, depth+1)
+		if !removed {
+			return n, false
+		}
+		children := *n.children
+		children[idx] = child
+		for _, c := range children {
+			if c != nil {
+				return &mapNode{children: &children}, true
+			}
+		}
+		return nil, true
+	}
+
+	for idx, e := range n.entries {
+		if e.hash != hash {
+			continue
+		}
+		if len(n.entries) == 1 {
+			return nil, true
+		}
+		entries := append(append([]mapEntry(nil), n.entries[:idx]...), n.entries[idx+1:]...)
+		return &mapNode{entries: entries}, true
+	}
+	return n, false
+}
+
+func (n *mapNode) walk(fn func(mapEntry)) {
+	switch {
+	case n == nil:
+	case n.children != nil:
+		for _, c := range n.children {
+			c.walk(fn)
+		}
+	default:
+		for _, e := range n.entries {
+			fn(e)
+		}
+	}
+}
+
+// mapSlot returns the index of the child of the branch at the depth.
+func mapSlot(bits uint64, depth int) int {
+	return int(bits>>(depth*mapBits)) & (mapWidth - 1)
+}
+
+// hashBits returns the FNV-1a hash of the string.
+func hashBits(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for idx := 0; idx < len(s); idx++ {
+		h ^= uint64(s[idx])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// hashKey returns the string, which is the same for the equal keys.
+// Numbers are hashed by their exact value, so that the numbers of
+// different kinds, which are equal, give the same hash.
+func hashKey(key Expression) (string, error) {
+	switch key := key.(type) {
+	case *Integer, *Rational:
+		return "n:" + toRat(key).RatString(), nil
+	case *Number:
+		switch {
+		case math.IsNaN(key.Value):
+			return "", ErrArgumentType{Expected: "map key", Actual: "NaN"}
+		case math.IsInf(key.Value, 0):
+			return "n:" + key.String(), nil
+		}
+		return "n:" + new(big.Rat).SetFloat64(key.Value).RatString(), nil
+	case *Boolean:
+		return fmt.Sprintf("b:%t", key.Value), nil
+	case *String:
+		return "s:" + key.Value, nil
+	case *Symbol:
+		return "y:" + key.Name, nil
+	}
+	return "", ErrArgumentType{Expected: "map key", Actual: key.Type()}
+}
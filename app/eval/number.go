@@ -0,0 +1,263 @@
+package eval
+
+import (
+	"math"
+	"math/big"
+)
+
+// Numbers come in three kinds: exact integers and rationals and inexact
+// floats, which are Number. All of them are of the "number" type, an
+// operation on numbers of different kinds gives the number of the wider
+// kind, in the order integer, rational, float.
+const (
+	kindInteger = iota
+	kindRational
+	kindFloat
+)
+
+// Integer represents an exact integer. It is kept in Value while it fits
+// in int64, and is promoted to Big otherwise.
+type Integer struct {
+	Value int64
+	Big   *big.Int `json:",omitempty"`
+}
+
+// NewInteger returns the integer of the given value, demoted to int64 if
+// it fits.
+func NewInteger(v *big.Int) *Integer {
+	if v.IsInt64() {
+		return &Integer{Value: v.Int64()}
+	}
+	return &Integer{Big: v}
+}
+
+// Type returns the type of the integer.
+func (i *Integer) Type() string { return "number" }
+
+// String returns the string representation of the integer.
+func (i *Integer) String() string { return i.big().String() }
+
+// Equal returns true if the number is numerically equal to the integer.
+func (i *Integer) Equal(b Expression) bool { return equalNumbers(i, b) }
+
+// FString returns the F language representation of the integer.
+func (i *Integer) FString() string { return i.String() }
+
+func (i *Integer) big() *big.Int {
+	if i.Big != nil {
+		return i.Big
+	}
+	return big.NewInt(i.Value)
+}
+
+// Rational represents an exact fraction, which is not an integer.
+type Rational struct{ Value *big.Rat }
+
+// NewRational returns the fraction, or the integer, if the denominator of
+// the fraction is 1.
+func NewRational(v *big.Rat) Expression {
+	if v.IsInt() {
+		return NewInteger(new(big.Int).Set(v.Num()))
+	}
+	return &Rational{Value: v}
+}
+
+// Type returns the type of the rational.
+func (r *Rational) Type() string { return "number" }
+
+// String returns the string representation of the rational, e.g. 1/3.
+func (r *Rational) String() string { return r.Value.String() }
+
+// Equal returns true if the number is numerically equal to the rational.
+func (r *Rational) Equal(b Expression) bool { return equalNumbers(r, b) }
+
+// FString returns the F language representation of the rational.
+func (r *Rational) FString() string { return r.String() }
+
+// numberKind returns the kind of the number, ok is false for any other
+// expression.
+func numberKind(e Expression) (kind int, ok bool) {
+	switch e.(type) {
+	case *Integer:
+		return kindInteger, true
+	case *Rational:
+		return kindRational, true
+	case *Number:
+		return kindFloat, true
+	}
+	return 0, false
+}
+
+// kindName returns the name of the kind of the number for error messages.
+func kindName(e Expression) string {
+	switch e.(type) {
+	case *Integer:
+		return "integer"
+	case *Rational:
+		return "rational"
+	case *Number:
+		return "float"
+	}
+	return e.Type()
+}
+
+func toRat(e Expression) *big.Rat {
+	switch e := e.(type) {
+	case *Integer:
+		return new(big.Rat).SetInt(e.big())
+	case *Rational:
+		return e.Value
+	}
+	panic("not an exact number")
+}
+
+func toFloat(e Expression) float64 {
+	switch e := e.(type) {
+	case *Integer:
+		if e.Big == nil {
+			return float64(e.Value)
+		}
+		f, _ := new(big.Float).SetInt(e.Big).Float64()
+		return f
+	case *Rational:
+		f, _ := e.Value.Float64()
+		return f
+	case *Number:
+		return e.Value
+	}
+	panic("not a number")
+}
+
+// numericOp is an arithmetic operation implemented for every kind.
+type numericOp struct {
+	// int64 reports false on overflow, then the operation is done on big
+	// integers
+	int64 func(a, b int64) (int64, bool)
+	big   func(z, a, b *big.Int) *big.Int
+	rat   func(z, a, b *big.Rat) *big.Rat
+	float func(a, b float64) float64
+}
+
+var (
+	opAdd = numericOp{
+		int64: func(a, b int64) (int64, bool) {
+			s := a + b
+			return s, (s > a) == (b > 0)
+		},
+		big:   (*big.Int).Add,
+		rat:   (*big.Rat).Add,
+		float: func(a, b float64) float64 { return a + b },
+	}
+	opSub = numericOp{
+		int64: func(a, b int64) (int64, bool) {
+			d := a - b
+			return d, (d < a) == (b > 0)
+		},
+		big:   (*big.Int).Sub,
+		rat:   (*big.Rat).Sub,
+		float: func(a, b float64) float64 { return a - b },
+	}
+	opMul = numericOp{
+		int64: func(a, b int64) (int64, bool) {
+			if a == 0 || b == 0 {
+				return 0, true
+			}
+			p := a * b
+			if a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64 {
+				return 0, false
+			}
+			return p, p/b == a
+		},
+		big:   (*big.Int).Mul,
+		rat:   (*big.Rat).Mul,
+		float: func(a, b float64) float64 { return a * b },
+	}
+)
+
+// apply applies the operation to the numbers, converting them to the wider
+// of their kinds.
+func (op numericOp) apply(a, b Expression) Expression {
+	ka, _ := numberKind(a)
+	kb, _ := numberKind(b)
+
+	switch {
+	case ka == kindFloat || kb == kindFloat:
+		return &Number{Value: op.float(toFloat(a), toFloat(b))}
+	case ka == kindRational || kb == kindRational:
+		return NewRational(op.rat(new(big.Rat), toRat(a), toRat(b)))
+	}
+
+	x, y := a.(*Integer), b.(*Integer)
+	if x.Big == nil && y.Big == nil {
+		if v, ok := op.int64(x.Value, y.Value); ok {
+			return &Integer{Value: v}
+		}
+	}
+	return NewInteger(op.big(new(big.Int), x.big(), y.big()))
+}
+
+// divideNumbers divides the numbers, the quotient of exact numbers is
+// exact, e.g. (divide 1 3) is 1/3.
+func divideNumbers(a, b Expression) (Expression, error) {
+	if isZero(b) {
+		return nil, ErrZeroDivision
+	}
+
+	ka, _ := numberKind(a)
+	kb, _ := numberKind(b)
+	if ka == kindFloat || kb == kindFloat {
+		return checkFloat(toFloat(a) / toFloat(b))
+	}
+
+	return NewRational(new(big.Rat).Quo(toRat(a), toRat(b))), nil
+}
+
+// compareNumbers returns -1, 0 or 1, if a is less, equal or greater than b.
+func compareNumbers(a, b Expression) int {
+	ka, _ := numberKind(a)
+	kb, _ := numberKind(b)
+
+	switch {
+	case ka == kindFloat || kb == kindFloat:
+		x, y := toFloat(a), toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case ka == kindRational || kb == kindRational:
+		return toRat(a).Cmp(toRat(b))
+	}
+
+	x, y := a.(*Integer), b.(*Integer)
+	if x.Big == nil && y.Big == nil {
+		switch {
+		case x.Value < y.Value:
+			return -1
+		case x.Value > y.Value:
+			return 1
+		}
+		return 0
+	}
+	return x.big().Cmp(y.big())
+}
+
+// equalNumbers reports whether both are numbers of equal value, regardless
+// of their kinds.
+func equalNumbers(a, b Expression) bool {
+	if _, ok := numberKind(b); !ok {
+		return false
+	}
+	for _, e := range []Expression{a, b} {
+		if n, ok := e.(*Number); ok && math.IsNaN(n.Value) {
+			return false
+		}
+	}
+	return compareNumbers(a, b) == 0
+}
+
+func isZero(e Expression) bool {
+	return compareNumbers(e, &Integer{}) == 0
+}
@@ -0,0 +1,257 @@
+package eval
+
+import "log"
+
+// Local is the reference to the parameter of a function, resolved ahead of
+// the evaluation: Depth is the number of functions between the reference
+// and the one the parameter belongs to, and Slot is the position of the
+// parameter.
+type Local struct {
+	Name  string
+	Depth int
+	Slot  int
+}
+
+// FString returns the F language representation of the reference.
+func (l *Local) FString() string { panic("must never be called") }
+
+// Type returns the type of the reference.
+func (l *Local) Type() string { return "identifier" }
+
+// String returns the string representation of the reference.
+func (l *Local) String() string { return l.Name }
+
+// Equal returns false, as references are never compared.
+func (*Local) Equal(Expression) bool {
+	log.Printf("[WARN] called Local.Equal()")
+	return false
+}
+
+// lookupLocal returns the value of the resolved parameter from the scope
+// of the function call it belongs to.
+func (s *Scope) lookupLocal(l *Local) Expression {
+	sc, depth := s, l.Depth
+	for ; ; sc = sc.Parent {
+		if sc.Context != "func" {
+			continue
+		}
+		if depth == 0 {
+			return sc.slots[l.Slot]
+		}
+		depth--
+	}
+}
+
+// frame is the function, which body is being resolved.
+type frame struct {
+	params []string        // nil, if the function has patterns as parameters
+	bound  map[string]bool // the names bound in the body besides parameters
+	opaque bool            // the body binds the names, which can't be told
+}
+
+// keywords are the names, which may appear in the forms, but aren't
+// references to variables.
+var keywords = map[string]bool{"else": true, "when": true, "_": true}
+
+// opaqueForms are the forms, which bind the names, that can't be told
+// from the source, e.g. the functions of records.
+var opaqueForms = map[string]bool{"deftype": true, "defstruct": true}
+
+// resolveFunc resolves the references to the parameters in the body of
+// the function, defined by the call of func or lambda, and in the bodies
+// of the functions nested in it, unless it is done already:
+//
+//	(func adder (n) (lambda (x) (plus x n)))
+//
+// here n in the body of the lambda is resolved to the depth 1 and slot 0,
+// and x to the depth 0 and slot 0.
+//
+// The reference is resolved only if it is sure to find the parameter: the
+// names, which are bound in the body of a function by any other means,
+// e.g. with let, setq or a pattern, are left to the lookup by name, as
+// well as the references to variables outside of functions.
+func resolveFunc(call *Call, params []Expression, body int) {
+	if call.resolved {
+		return
+	}
+	(&resolver{}).function(call, params, body)
+}
+
+type resolver struct {
+	frames []*frame // the innermost function goes last
+}
+
+// function resolves the body of the function, which is the argument of the
+// call at the index body.
+func (r *resolver) function(call *Call, params []Expression, body int) {
+	call.resolved = true
+
+	f := &frame{bound: map[string]bool{}}
+	for _, param := range params {
+		id, ok := param.(*Identifier)
+		if !ok {
+			// the variables of patterns are bound by name
+			f.params = nil
+			f.names(&List{Values: params})
+			break
+		}
+		f.params = append(f.params, id.Name)
+	}
+	f.collect(call.Args[body])
+
+	r.frames = append(r.frames, f)
+	call.Args[body] = r.expr(call.Args[body])
+	r.frames = r.frames[:len(r.frames)-1]
+}
+
+// expr returns the expression with the references resolved.
+func (r *resolver) expr(expr Expression) Expression {
+	switch expr := expr.(type) {
+	case *Identifier:
+		if l, ok := r.lookup(expr.Name); ok {
+			return l
+		}
+	case *List:
+		for idx, val := range expr.Values {
+			expr.Values[idx] = r.expr(val)
+		}
+	case *Call:
+		r.call(expr)
+	}
+	return expr
+}
+
+func (r *resolver) call(call *Call) {
+	switch call.Name {
+	case "quote":
+		return
+	case "func":
+		if len(call.Args) >= 3 {
+			if list, ok := call.Args[1].(*List); ok {
+				r.function(call, list.Values, 2)
+			}
+		}
+		return
+	case "lambda":
+		if len(call.Args) == 2 {
+			if list, ok := call.Args[0].(*List); ok {
+				r.function(call, list.Values, 1)
+			}
+		}
+		return
+	case "setq", "set!", "define", "const":
+		// the name is taken by the form as it is
+		if len(call.Args) == 2 {
+			call.Args[1] = r.expr(call.Args[1])
+		}
+		return
+	}
+
+	for idx, arg := range call.Args {
+		call.Args[idx] = r.expr(arg)
+	}
+}
+
+// lookup returns the reference to the parameter of the name, if it is sure
+// to be the one the name refers to.
+func (r *resolver) lookup(name string) (*Local, bool) {
+	if keywords[name] {
+		return nil, false
+	}
+
+	for depth := 0; depth < len(r.frames); depth++ {
+		f := r.frames[len(r.frames)-1-depth]
+		if f.opaque || f.bound[name] {
+			return nil, false
+		}
+		for slot := len(f.params) - 1; slot >= 0; slot-- {
+			if f.params[slot] == name {
+				return &Local{Name: name, Depth: depth, Slot: slot}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// collect puts the names, which the expression binds in the scopes of the
+// function, to the bound ones. The bodies of nested functions are left to
+// their own frames, though their names are bound in the function.
+func (f *frame) collect(expr Expression) {
+	call, ok := expr.(*Call)
+	if !ok {
+		if list, ok := expr.(*List); ok {
+			for _, val := range list.Values {
+				f.collect(val)
+			}
+		}
+		return
+	}
+
+	if opaqueForms[call.Name] {
+		f.opaque = true
+		return
+	}
+
+	switch call.Name {
+	case "quote", "lambda":
+		return
+	case "func":
+		if len(call.Args) > 0 {
+			f.names(call.Args[0])
+		}
+		return
+	case "setq", "define", "const", "catch", "prog":
+		if len(call.Args) > 0 {
+			f.names(call.Args[0])
+		}
+	case "let", "let*", "letrec", "for-each", "dotimes", "for":
+		// the first element of a binding, or of the loop specification
+		if len(call.Args) > 0 {
+			f.first(call.Args[0], call.Name == "let" || call.Name == "let*" || call.Name == "letrec")
+		}
+	case "match":
+		for idx, arg := range call.Args {
+			if clause, ok := arg.(*List); ok && idx > 0 && len(clause.Values) > 0 {
+				f.names(clause.Values[0])
+			}
+		}
+	}
+
+	for _, arg := range call.Args {
+		f.collect(arg)
+	}
+}
+
+// first puts the names of the first elements of the bindings, if the list
+// is the one of bindings, or of the list itself to the bound ones.
+func (f *frame) first(expr Expression, bindings bool) {
+	list, ok := expr.(*List)
+	if !ok || len(list.Values) == 0 {
+		return
+	}
+	if !bindings {
+		f.names(list.Values[0])
+		return
+	}
+	for _, b := range list.Values {
+		if pair, ok := b.(*List); ok && len(pair.Values) > 0 {
+			f.names(pair.Values[0])
+		}
+	}
+}
+
+// names puts all identifiers of the name or pattern to the bound ones.
+func (f *frame) names(expr Expression) {
+	switch expr := expr.(type) {
+	case *Identifier:
+		f.bound[expr.Name] = true
+	case *List:
+		for _, val := range expr.Values {
+			f.names(val)
+		}
+	case *Call:
+		for _, arg := range expr.Args {
+			f.names(arg)
+		}
+	}
+}
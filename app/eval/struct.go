@@ -0,0 +1,62 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StructDef describes the record type, declared with defstruct.
+type StructDef struct {
+	Name   string
+	Fields []string
+}
+
+// field returns the position of the field, or -1, if there is no such one.
+func (d *StructDef) field(name string) int {
+	for idx, field := range d.Fields {
+		if field == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// Struct represents a record, its values go in the order of the fields of
+// its definition.
+type Struct struct {
+	Def    *StructDef
+	Values []Expression
+}
+
+// Type returns the name of the record type.
+func (s *Struct) Type() string { return s.Def.Name }
+
+// String returns the string representation of the record.
+func (s *Struct) String() string { return s.format(Expression.String) }
+
+// FString returns the F language representation of the record, which
+// shows the field names, e.g. point{x: 1, y: 2}.
+func (s *Struct) FString() string { return s.format(Expression.FString) }
+
+// Equal returns true if both records are of the type of the same name and
+// have equal values of the fields.
+func (s *Struct) Equal(e Expression) bool {
+	s2, ok := e.(*Struct)
+	if !ok || s.Def.Name != s2.Def.Name || len(s.Values) != len(s2.Values) {
+		return false
+	}
+	for idx, val := range s.Values {
+		if s.Def.Fields[idx] != s2.Def.Fields[idx] || !val.Equal(s2.Values[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Struct) format(str func(Expression) string) string {
+	fields := make([]string, len(s.Values))
+	for idx, val := range s.Values {
+		fields[idx] = fmt.Sprintf("%s: %s", s.Def.Fields[idx], str(val))
+	}
+	return fmt.Sprintf("%s{%s}", s.Def.Name, strings.Join(fields, ", "))
+}
@@ -0,0 +1,129 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Position is the place of the expression in the source, the zero value
+// means the place is unknown, e.g. for the expressions built by hand.
+type Position struct {
+	Line, Col int
+}
+
+// String returns the position as line:col.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return "?"
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Frame is the call of the function in progress.
+type Frame struct {
+	Function string
+	Args     []Expression
+	Pos      Position // the place of the call
+}
+
+// String returns the call of the frame as it would be written, with the
+// values of the arguments, e.g. (fact 3) at 5:1.
+func (f Frame) String() string {
+	call := []string{f.Function}
+	for _, arg := range f.Args {
+		call = append(call, arg.FString())
+	}
+	return fmt.Sprintf("(%s) at %s", strings.Join(call, " "), f.Pos)
+}
+
+// EvalError is the error of the evaluation with the call, which failed,
+// and the calls of the functions, which were in progress, when it failed.
+type EvalError struct {
+	Err   error
+	Call  string   // the name of the call, which failed
+	Pos   Position // the place of the call, which failed
+	Stack []Frame  // the outermost call goes first
+}
+
+// Error returns string representation of the error.
+func (e *EvalError) Error() string {
+	return fmt.Sprintf("call %q at %s: %v", e.Call, e.Pos, e.Err)
+}
+
+// Code returns the stable code of the error, see ErrorCode.
+func (e *EvalError) Code() string { return ErrorCode(e.Err) }
+
+// Unwrap returns the error of the call.
+func (e *EvalError) Unwrap() error { return e.Err }
+
+// Traceback returns the error with the calls in progress, the innermost
+// call goes first:
+//
+//	call "divide" at 2:5: zero division
+//	    in (average '()) at 4:1
+//
+// The calls of the same function from the same place in a row, e.g. of a
+// recursion, are shown once with the number of the rest of them, and only
+// the innermost and the outermost calls are shown of a stack too deep.
+func (e *EvalError) Traceback() string {
+	var groups []frameGroup
+	for idx := len(e.Stack) - 1; idx >= 0; idx-- {
+		frame := e.Stack[idx]
+		if last := len(groups) - 1; last >= 0 && groups[last].frame.sameCall(frame) {
+			groups[last].repeated++
+			continue
+		}
+		groups = append(groups, frameGroup{frame: frame})
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(e.Error())
+	for idx, group := range groups {
+		if len(groups) > maxTraceback && idx == maxTraceback-tracebackTail {
+			fmt.Fprintf(sb, "\n    ... %d more calls", len(groups)-maxTraceback)
+		}
+		if len(groups) > maxTraceback && idx >= maxTraceback-tracebackTail && idx < len(groups)-tracebackTail {
+			continue
+		}
+		sb.WriteString("\n    in ")
+		sb.WriteString(group.frame.String())
+		if group.repeated > 0 {
+			fmt.Fprintf(sb, "\n    ... repeated %d more times", group.repeated)
+		}
+	}
+	return sb.String()
+}
+
+const (
+	maxTraceback  = 20 // the number of the calls shown at most
+	tracebackTail = 5  // the number of the outermost calls shown of them
+)
+
+// frameGroup is the call with the number of the same calls after it.
+type frameGroup struct {
+	frame    Frame
+	repeated int
+}
+
+// sameCall reports whether both frames are the calls of the same function
+// from the same place, whatever the arguments.
+func (f Frame) sameCall(other Frame) bool {
+	return f.Function == other.Function && f.Pos == other.Pos
+}
+
+// fail returns the error of the call with the stack of the calls in
+// progress. The error of a nested call has the stack already, so it is
+// returned as it is, without the calls it was wrapped with on the way up.
+func (e *execution) fail(call *Call, err error) error {
+	var evalErr *EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr
+	}
+	return &EvalError{
+		Err:   err,
+		Call:  call.Name,
+		Pos:   call.Pos,
+		Stack: append([]Frame(nil), e.stack...),
+	}
+}
@@ -0,0 +1,63 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeDef describes the algebraic data type, declared with deftype, which
+// values are of one of its variants.
+type TypeDef struct {
+	Name     string
+	Variants []*VariantDef
+}
+
+// VariantDef describes the variant of the type with its fields.
+type VariantDef struct {
+	Name   string
+	Fields []string
+	Type   *TypeDef
+}
+
+// Variant represents a value of the algebraic data type.
+type Variant struct {
+	Def    *VariantDef
+	Values []Expression
+}
+
+// Type returns the name of the type of the variant.
+func (v *Variant) Type() string { return v.Def.Type.Name }
+
+// String returns the string representation of the variant.
+func (v *Variant) String() string { return v.format(Expression.String) }
+
+// FString returns the F language representation of the variant, which is
+// the call of its constructor, e.g. (circle 1), or the name of the variant
+// without fields.
+func (v *Variant) FString() string { return v.format(Expression.FString) }
+
+// Equal returns true if both values are of the variant of the same name
+// of the same type and have equal fields.
+func (v *Variant) Equal(e Expression) bool {
+	v2, ok := e.(*Variant)
+	if !ok || v.Def.Name != v2.Def.Name || v.Type() != v2.Type() || len(v.Values) != len(v2.Values) {
+		return false
+	}
+	for idx, val := range v.Values {
+		if !val.Equal(v2.Values[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *Variant) format(str func(Expression) string) string {
+	if len(v.Def.Fields) == 0 {
+		return v.Def.Name
+	}
+	args := []string{v.Def.Name}
+	for _, val := range v.Values {
+		args = append(args, str(val))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(args, " "))
+}
@@ -10,6 +10,7 @@ import (
 type Lexer struct {
 	rd           *bufio.Reader
 	cursor       Cursor
+	prev         Cursor // the cursor before the last read rune
 	readComments bool
 	lastToken    struct {
 		value Token
@@ -21,7 +22,7 @@ type Lexer struct {
 func NewLexer(rd io.Reader) *Lexer {
 	return &Lexer{
 		rd:     bufio.NewReader(rd),
-		cursor: Cursor{Line: 1, Col: 1},
+		cursor: Cursor{Line: 1},
 	}
 }
 
@@ -54,10 +55,16 @@ func (l *Lexer) NextToken() (Token, error) {
 		tkn = Token{Type: RParen}
 	case isDigit(r):
 		tkn = l.readNumber(r)
-	case r == '_', isLetter(r):
+	case r == '"':
+		if tkn, err = l.readString(); err != nil {
+			return Token{}, err
+		}
+	case r == '_', r == ':', isLetter(r):
 		tkn = l.readIdentifier(r)
 	case r == '/':
-		tkn = l.readComment(r)
+		if tkn, err = l.readComment(r); err != nil {
+			return Token{}, err
+		}
 		if !l.readComments {
 			if tkn, err = l.NextToken(); err != nil {
 				return Token{}, err
@@ -89,8 +96,8 @@ func (l *Lexer) readIdentifier(r rune) Token {
 			return Token{Type: Identifier, Value: string(*sb)}
 		}
 
-		if !isLetter(r) && !isDigit(r) && r != '_' {
-			l.rd.UnreadRune()
+		if !isLetter(r) && !isDigit(r) && !isIdentifierSymbol(r) {
+			l.unreadRune()
 			return Token{Type: Identifier, Value: string(*sb)}
 		}
 
@@ -98,6 +105,8 @@ func (l *Lexer) readIdentifier(r rune) Token {
 	}
 }
 
+// readNumber reads the integer, the float, e.g. 1.5, or the rational, e.g.
+// 1/2, literal.
 func (l *Lexer) readNumber(r rune) Token {
 	var sb = &[]rune{}
 	*sb = append(*sb, r)
@@ -108,8 +117,8 @@ func (l *Lexer) readNumber(r rune) Token {
 			return Token{Type: Number, Value: string(*sb)}
 		}
 
-		if !isDigit(r) && r != '.' {
-			l.rd.UnreadRune()
+		if !isDigit(r) && r != '.' && r != '/' {
+			l.unreadRune()
 			return Token{Type: Number, Value: string(*sb)}
 		}
 
@@ -117,14 +126,57 @@ func (l *Lexer) readNumber(r rune) Token {
 	}
 }
 
-func (l *Lexer) readComment(r rune) Token {
+// readString reads the string literal up to the closing quote, the value
+// of the token is the unescaped string.
+func (l *Lexer) readString() (Token, error) {
+	var sb = &[]rune{}
+
+	for {
+		r, _, err := l.readRune()
+		if err != nil {
+			return Token{}, fmt.Errorf("unterminated string at %s: %w", l.cursor, err)
+		}
+
+		switch r {
+		case '"':
+			return Token{Type: String, Value: string(*sb)}, nil
+		case '\\':
+			if r, _, err = l.readRune(); err != nil {
+				return Token{}, fmt.Errorf("unterminated string at %s: %w", l.cursor, err)
+			}
+			switch r {
+			case 'n':
+				r = '\n'
+			case 't':
+				r = '\t'
+			case '"', '\\':
+			default:
+				return Token{}, fmt.Errorf("unknown escape sequence at %s: \\%c", l.cursor, r)
+			}
+		}
+
+		*sb = append(*sb, r)
+	}
+}
+
+func (l *Lexer) readComment(r rune) (Token, error) {
 	var sb = &[]rune{}
 	*sb = append(*sb, r)
 
+	// a single slash is no comment, and no operator either
+	next, _, err := l.readRune()
+	if err != nil || next != '/' {
+		if err == nil {
+			l.unreadRune()
+		}
+		return Token{}, fmt.Errorf("unexpected symbol at %s: %c", l.cursor, r)
+	}
+	*sb = append(*sb, next)
+
 	for {
 		r, _, err := l.readRune()
 		if err != nil || r == '\n' {
-			return Token{Type: Comment, Value: string(*sb)}
+			return Token{Type: Comment, Value: string(*sb)}, nil
 		}
 
 		*sb = append(*sb, r)
@@ -140,6 +192,7 @@ func (l *Lexer) readRune() (r rune, size int, err error) {
 		return
 	}
 
+	l.prev = l.cursor
 	l.cursor.Col++
 
 	if r == '\n' {
@@ -150,6 +203,13 @@ func (l *Lexer) readRune() (r rune, size int, err error) {
 	return
 }
 
+// unreadRune unreads the last read rune, along with the move of the
+// cursor.
+func (l *Lexer) unreadRune() {
+	_ = l.rd.UnreadRune()
+	l.cursor = l.prev
+}
+
 type Cursor struct {
 	Line, Col int
 }
@@ -162,6 +222,12 @@ func isDigit(r rune) bool {
 	return r >= '0' && r <= '9'
 }
 
+// isIdentifierSymbol reports whether the rune may appear in an identifier
+// after its first symbol, e.g. let*, for-each, error? or set!.
+func isIdentifierSymbol(r rune) bool {
+	return r == '_' || r == '*' || r == '-' || r == '?' || r == '!'
+}
+
 func isLetter(r rune) bool {
 	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
 }
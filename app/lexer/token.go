@@ -7,6 +7,7 @@ const (
 	LParen     TokenType = "("
 	RParen     TokenType = ")"
 	Number     TokenType = "number"
+	String     TokenType = "string"
 	Identifier TokenType = "identifier"
 	Comment    TokenType = "comment"
 )
@@ -4,6 +4,8 @@ import (
 	"github.com/cappuccinotm/flangc/app/lexer"
 	"fmt"
 	"strconv"
+	"strings"
+	"math/big"
 	"errors"
 	"github.com/cappuccinotm/flangc/app/eval"
 )
@@ -28,27 +30,48 @@ func (p *Parser) ParseNext() (eval.Expression, error) {
 
 	switch tkn.Type {
 	case lexer.SQuote:
-		expr, err := p.parseTuple()
+		expr, err := p.parseQuoted()
 		if err != nil {
-			return nil, fmt.Errorf("parse list: %w", err)
+			return nil, fmt.Errorf("parse quoted expression at %s: %w", cursor, err)
 		}
 		return expr, nil
 	case lexer.LParen:
 		cursor = p.l.Cursor()
-		if tkn, err = p.readAndValidateToken(lexer.Identifier); err != nil {
+		if tkn, err = p.l.NextToken(); err != nil {
 			return nil, fmt.Errorf("get next token at %s: %w", cursor, err)
 		}
 
+		if tkn.Type == lexer.LParen {
+			p.l.UnreadToken()
+			expr, err := p.parseFuncall()
+			if err != nil {
+				return nil, fmt.Errorf("parse call at %s: %w", cursor, err)
+			}
+			return at(expr, cursor), nil
+		}
+
+		if tkn.Type != lexer.Identifier {
+			return nil, fmt.Errorf("get next token at %s: expected %s, got: %s", cursor, lexer.Identifier, tkn)
+		}
+
 		expr, err := p.parseCall(tkn)
 		if err != nil {
 			return nil, fmt.Errorf("parse call at %s: %w", cursor, err)
 		}
-		return expr, nil
+		return at(expr, cursor), nil
 	default:
 		return nil, fmt.Errorf("unexpected token at %s: %s", cursor, tkn)
 	}
 }
 
+// at sets the position of the call to the one of its opening parenthesis.
+func at(expr eval.Expression, cursor lexer.Cursor) eval.Expression {
+	if call, ok := expr.(*eval.Call); ok {
+		call.Pos = eval.Position{Line: cursor.Line, Col: cursor.Col}
+	}
+	return expr
+}
+
 // parses (el1 el2 el3) as list, without counting quote sign '
 func (p *Parser) parseTuple() (eval.Expression, error) {
 	var exprs []eval.Expression
@@ -65,13 +88,15 @@ func (p *Parser) parseTuple() (eval.Expression, error) {
 
 		switch tkn.Type {
 		case lexer.Number:
-			f, err := strconv.ParseFloat(tkn.Value, 64)
+			n, err := parseNumber(tkn.Value)
 			if err != nil {
-				return nil, fmt.Errorf("parse number: %w", err)
+				return nil, err
 			}
-			exprs = append(exprs, &eval.Number{Value: f})
+			exprs = append(exprs, n)
 		case lexer.Identifier:
 			exprs = append(exprs, parseIdentifier(tkn.Value))
+		case lexer.String:
+			exprs = append(exprs, &eval.String{Value: tkn.Value})
 		case lexer.RParen:
 			return &eval.List{Values: exprs}, nil
 		case lexer.LParen, lexer.SQuote:
@@ -87,6 +112,103 @@ func (p *Parser) parseTuple() (eval.Expression, error) {
 	}
 }
 
+// parses the expression after the quote sign ' as data: identifiers are
+// taken for symbols, e.g. 'red, and parenthesized expressions for lists,
+// which may nest, e.g. '((1 a) (2 b)).
+func (p *Parser) parseQuoted() (eval.Expression, error) {
+	tkn, err := p.l.NextToken()
+	if err != nil {
+		return nil, fmt.Errorf("get next token: %w", err)
+	}
+
+	switch tkn.Type {
+	case lexer.Number:
+		return parseNumber(tkn.Value)
+	case lexer.String:
+		return &eval.String{Value: tkn.Value}, nil
+	case lexer.Identifier:
+		if expr := parseIdentifier(tkn.Value); !isIdentifier(expr) {
+			return expr, nil
+		}
+		return &eval.Symbol{Name: tkn.Value}, nil
+	case lexer.SQuote:
+		return p.parseQuoted()
+	case lexer.LParen:
+	default:
+		return nil, fmt.Errorf("unexpected token: %s", tkn)
+	}
+
+	var exprs []eval.Expression
+	for {
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		if tkn.Type == lexer.RParen {
+			return &eval.List{Values: exprs}, nil
+		}
+
+		p.l.UnreadToken()
+		expr, err := p.parseQuoted()
+		if err != nil {
+			return nil, fmt.Errorf("parse element %d: %w", len(exprs), err)
+		}
+		exprs = append(exprs, expr)
+	}
+}
+
+func isIdentifier(expr eval.Expression) bool {
+	_, ok := expr.(*eval.Identifier)
+	return ok
+}
+
+// parseNumber returns an integer for the literal without a fractional part,
+// a rational for the fraction, e.g. 1/2, and a float otherwise.
+func parseNumber(value string) (eval.Expression, error) {
+	if strings.Contains(value, "/") {
+		num, den, err := parseFraction(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse number: %w", err)
+		}
+		return eval.NewRational(new(big.Rat).SetFrac(num, den)), nil
+	}
+
+	if !strings.Contains(value, ".") {
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return nil, fmt.Errorf("parse number: invalid integer %q", value)
+		}
+		return eval.NewInteger(n), nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse number: %w", err)
+	}
+	return &eval.Number{Value: f}, nil
+}
+
+// parseFraction returns the numerator and the denominator of the rational
+// literal, both of which must be integers.
+func parseFraction(value string) (num, den *big.Int, err error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return nil, nil, fmt.Errorf("invalid rational %q", value)
+	}
+
+	num, ok := new(big.Int).SetString(parts[0], 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid rational %q", value)
+	}
+	if den, ok = new(big.Int).SetString(parts[1], 10); !ok {
+		return nil, nil, fmt.Errorf("invalid rational %q", value)
+	}
+	if den.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid rational %q: zero denominator", value)
+	}
+	return num, den, nil
+}
+
 func parseIdentifier(value string) eval.Expression {
 	switch value {
 	case "true":
@@ -113,7 +235,32 @@ func (p *Parser) parseCall(tkn lexer.Token) (eval.Expression, error) {
 		return expr, nil
 	}
 
-	result := &eval.Call{Name: tkn.Value}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &eval.Call{Name: tkn.Value, Args: args}, nil
+}
+
+// parses ((function) args...) as funcall(function, args...)
+func (p *Parser) parseFuncall() (eval.Expression, error) {
+	fn, err := p.ParseNext()
+	if err != nil {
+		return nil, fmt.Errorf("parse function: %w", err)
+	}
+
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	return &eval.Call{Name: "funcall", Args: append([]eval.Expression{fn}, args...)}, nil
+}
+
+// parses expressions up to the closing parenthesis
+func (p *Parser) parseArgs() ([]eval.Expression, error) {
+	var args []eval.Expression
 
 	for {
 		tkn, err := p.l.NextToken()
@@ -121,26 +268,39 @@ func (p *Parser) parseCall(tkn lexer.Token) (eval.Expression, error) {
 			return nil, fmt.Errorf("get next token: %w", err)
 		}
 
-		switch tkn.Type {
-		case lexer.Identifier:
-			result.Args = append(result.Args, parseIdentifier(tkn.Value))
-		case lexer.Number:
-			f, err := strconv.ParseFloat(tkn.Value, 64)
-			if err != nil {
-				return nil, fmt.Errorf("parse number: %w", err)
-			}
-			result.Args = append(result.Args, &eval.Number{Value: f})
-		case lexer.RParen:
-			return result, nil
-		case lexer.LParen, lexer.SQuote:
-			p.l.UnreadToken()
-			expr, err := p.ParseNext()
-			if err != nil {
-				return nil, fmt.Errorf("parse expression: %w", err)
-			}
-			result.Args = append(result.Args, expr)
+		if tkn.Type == lexer.RParen {
+			return args, nil
 		}
+
+		expr, err := p.parseExpr(tkn)
+		if err != nil {
+			return nil, err
+		}
+
+		if expr != nil {
+			args = append(args, expr)
+		}
+	}
+}
+
+// parses a single expression, starting with the given token
+func (p *Parser) parseExpr(tkn lexer.Token) (eval.Expression, error) {
+	switch tkn.Type {
+	case lexer.Identifier:
+		return parseIdentifier(tkn.Value), nil
+	case lexer.Number:
+		return parseNumber(tkn.Value)
+	case lexer.String:
+		return &eval.String{Value: tkn.Value}, nil
+	case lexer.LParen, lexer.SQuote:
+		p.l.UnreadToken()
+		expr, err := p.ParseNext()
+		if err != nil {
+			return nil, fmt.Errorf("parse expression: %w", err)
+		}
+		return expr, nil
 	}
+	return nil, nil
 }
 
 func (p *Parser) readAndValidateToken(typ lexer.TokenType) (lexer.Token, error) {
@@ -0,0 +1,116 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/lexer"
+	"github.com/cappuccinotm/flangc/app/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser_Cond(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "if-then-else", src: "(cond (empty l) 0 (head l))", want: "cond(empty(l), 0, head(l))"},
+		{name: "calls without else", src: "(cond (x 1) (y 2))", want: "cond(x(1), y(2))"},
+		{name: "call of a call", src: "(cond (funcall (f 1) 2) 3)", want: "cond(funcall(f(1), 2), 3)"},
+		{name: "special form", src: "(cond (let () true) 1)", want: "cond(let([], true), 1)"},
+		{name: "clauses with else", src: "(cond (x 1) (y 2) (else 3))", want: "cond([x, 1], [y, 2], [else, 3])"},
+		{name: "parenthesized tests", src: "(cond ((f 1) 2) (else 3))", want: "cond([f(1), 2], [else, 3])"},
+		{
+			name: "parenthesized tests without else",
+			src:  "(cond ((less x 0) 1) ((less x 10) 2))",
+			want: "cond([less(x, 0), 1], [less(x, 10), 2])",
+		},
+		{name: "parenthesized test and calls", src: "(cond (x 1) ((f) 2))", want: "cond([x, 1], [f(), 2])"},
+		{name: "literal test", src: "(cond (true 1) (else 2))", want: "cond([true, 1], [else, 2])"},
+		{name: "literal tests without else", src: "(cond (true 1) (false 2))", want: "cond([true, 1], [false, 2])"},
+		{name: "test without a body", src: "(cond (x) (else))", want: "cond([x], [else])"},
+		{name: "else is not last", src: "(cond (x 1) (else 2) (y 3))", want: "cond([x, 1], [else, 2], [y, 3])"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parse(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, expr.String())
+		})
+	}
+}
+
+func TestParser_CondErrors(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+	}{
+		{name: "expression with a parenthesized test", src: "(cond ((f 1) 2) 3)"},
+		{name: "too many calls", src: "(cond (x 1) (y 2) (z 3) (w 4))"},
+		{name: "expression with else", src: "(cond x (else 1))"},
+		{name: "special form with else", src: "(cond (let () true) (else 1))"},
+		{name: "empty clause", src: "(cond () (else 1))"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParser_Numbers(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "integer", src: "(f 12)", want: "f(12)"},
+		{name: "float", src: "(f 1.5 2.0)", want: "f(1.5, 2.0)"},
+		{name: "rational", src: "(f 1/2)", want: "f(1/2)"},
+		{name: "rational in lowest terms", src: "(f 2/4)", want: "f(1/2)"},
+		{name: "whole rational", src: "(f 4/2)", want: "f(2)"},
+		{name: "comment after a number", src: "(f 1 // 2\n 3)", want: "f(1, 3)"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parse(tt.src)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, expr.String())
+		})
+	}
+}
+
+func TestParser_NumberErrors(t *testing.T) {
+	tbl := []struct {
+		name string
+		src  string
+	}{
+		{name: "zero denominator", src: "(f 1/0)"},
+		{name: "float numerator", src: "(f 1.5/2)"},
+		{name: "two slashes", src: "(f 1/2/3)"},
+		{name: "no denominator", src: "(f 1/)"},
+		{name: "single slash", src: "(f 1 / 2)"},
+	}
+
+	for _, tt := range tbl {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.src)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func parse(src string) (eval.Expression, error) {
+	return parser.NewParser(lexer.NewLexer(strings.NewReader(src))).ParseNext()
+}
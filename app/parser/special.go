@@ -19,6 +19,16 @@ func (p *Parser) findReservedKeyword(tkn lexer.Token) (expr eval.Expression, err
 		expr, err = p.parseProg()
 	case "while":
 		expr, err = p.parseWhile()
+	case "let", "let*", "letrec":
+		expr, err = p.parseLet(tkn.Value)
+	case "for-each", "dotimes", "for":
+		expr, err = p.parseLoop(tkn.Value)
+	case "cond":
+		expr, err = p.parseCond()
+	case "case":
+		expr, err = p.parseCase()
+	case "match":
+		expr, err = p.parseMatch()
 	default:
 		return nil, errNoReservedKeyword
 	}
@@ -30,7 +40,8 @@ func (p *Parser) findReservedKeyword(tkn lexer.Token) (expr eval.Expression, err
 }
 
 // (func name (args) (body))
-// func(name, [args], body(...))
+// (func name (args) :pre (condition) :post (condition) (body))
+// func(name, [args], body(...)), func(name, [args], body(...), [pre...], [post...])
 func (p *Parser) parseFunc() (eval.Expression, error) {
 	tkn, err := p.readAndValidateToken(lexer.Identifier)
 	if err != nil {
@@ -46,22 +57,36 @@ func (p *Parser) parseFunc() (eval.Expression, error) {
 
 	result.Args = append(result.Args, expr)
 
-	if _, err = p.readAndValidateToken(lexer.LParen); err != nil {
+	body, err := p.parseArgs()
+	if err != nil {
 		return nil, err
 	}
 
-	if tkn, err = p.readAndValidateToken(lexer.Identifier); err != nil {
-		return nil, err
+	pre, post := &eval.List{}, &eval.List{}
+	for len(body) > 2 {
+		id, ok := body[0].(*eval.Identifier)
+		if !ok {
+			break
+		}
+		switch id.Name {
+		case ":pre":
+			pre.Values = append(pre.Values, body[1])
+		case ":post":
+			post.Values = append(post.Values, body[1])
+		default:
+			return nil, fmt.Errorf("expected :pre or :post, got %s", id.Name)
+		}
+		body = body[2:]
 	}
 
-	if expr, err = p.parseCall(tkn); err != nil {
-		return nil, err
+	if len(body) != 1 {
+		return nil, fmt.Errorf("expected single body expression, got %d", len(body))
 	}
 
-	result.Args = append(result.Args, expr)
+	result.Args = append(result.Args, body[0])
 
-	if _, err = p.readAndValidateToken(lexer.RParen); err != nil {
-		return nil, err
+	if len(pre.Values) > 0 || len(post.Values) > 0 {
+		result.Args = append(result.Args, pre, post)
 	}
 
 	return result, nil
@@ -78,24 +103,16 @@ func (p *Parser) parseLambda() (eval.Expression, error) {
 
 	result.Args = append(result.Args, expr)
 
-	if _, err = p.readAndValidateToken(lexer.LParen); err != nil {
-		return nil, err
-	}
-
-	tkn, err := p.readAndValidateToken(lexer.Identifier)
+	body, err := p.parseArgs()
 	if err != nil {
 		return nil, err
 	}
 
-	if expr, err = p.parseCall(tkn); err != nil {
-		return nil, err
+	if len(body) != 1 {
+		return nil, fmt.Errorf("expected single body expression, got %d", len(body))
 	}
 
-	result.Args = append(result.Args, expr)
-
-	if _, err = p.readAndValidateToken(lexer.RParen); err != nil {
-		return nil, err
-	}
+	result.Args = append(result.Args, body[0])
 
 	return result, nil
 }
@@ -124,43 +141,407 @@ func (p *Parser) parseProg() (eval.Expression, error) {
 	return result, nil
 }
 
-// (while (predicate) (statements))
+// (while (predicate) (statement) ...)
 func (p *Parser) parseWhile() (eval.Expression, error) {
-	result := &eval.Call{Name: "while", Args: nil}
+	args, err := p.parseArgs()
+	if err != nil {
+		return nil, err
+	}
 
+	if len(args) < 2 {
+		return nil, errors.New("expected predicate and loop body")
+	}
+
+	return &eval.Call{Name: "while", Args: args}, nil
+}
+
+// (for-each (name list) (statement) ...)
+// (dotimes (name count) (statement) ...)
+// (for (name start end step) (statement) ...)
+// name([name, values...], statements...)
+func (p *Parser) parseLoop(name string) (eval.Expression, error) {
 	if _, err := p.readAndValidateToken(lexer.LParen); err != nil {
 		return nil, err
 	}
-	
+
 	tkn, err := p.readAndValidateToken(lexer.Identifier)
 	if err != nil {
 		return nil, err
 	}
 
-	expr, err := p.parseCall(tkn)
+	values, err := p.parseArgs()
+	if err != nil {
+		return nil, fmt.Errorf("parse loop variable %s: %w", tkn.Value, err)
+	}
+
+	body, err := p.parseArgs()
 	if err != nil {
 		return nil, err
 	}
 
-	result.Args = append(result.Args, expr)
+	if len(body) == 0 {
+		return nil, errors.New("expected loop body")
+	}
 
-	if _, err = p.readAndValidateToken(lexer.LParen); err != nil {
+	spec := &eval.List{Values: append([]eval.Expression{&eval.Identifier{Name: tkn.Value}}, values...)}
+	return &eval.Call{Name: name, Args: append([]eval.Expression{spec}, body...)}, nil
+}
+
+// (let ((name value) ...) (body) ...)
+// let([[name, value], ...], body...)
+func (p *Parser) parseLet(name string) (eval.Expression, error) {
+	bindings, err := p.parseBindings()
+	if err != nil {
 		return nil, err
 	}
 
-	if tkn, err = p.readAndValidateToken(lexer.Identifier); err != nil {
+	body, err := p.parseArgs()
+	if err != nil {
 		return nil, err
 	}
 
-	if expr, err = p.parseCall(tkn); err != nil {
+	return &eval.Call{Name: name, Args: append([]eval.Expression{bindings}, body...)}, nil
+}
+
+// ((name value) ...), ((pattern value) ...)
+func (p *Parser) parseBindings() (eval.Expression, error) {
+	if _, err := p.readAndValidateToken(lexer.LParen); err != nil {
 		return nil, err
 	}
 
-	result.Args = append(result.Args, expr)
+	result := &eval.List{}
+
+	for {
+		tkn, err := p.l.NextToken()
+		if err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		switch tkn.Type {
+		case lexer.RParen:
+			return result, nil
+		case lexer.LParen:
+		default:
+			return nil, fmt.Errorf("expected binding, got: %s", tkn)
+		}
+
+		// the name may be a pattern to destructure the value,
+		// e.g. ((cons h t) list)
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		var name eval.Expression
+		switch tkn.Type {
+		case lexer.Identifier:
+			name = &eval.Identifier{Name: tkn.Value}
+		case lexer.LParen:
+			p.l.UnreadToken()
+			if name, err = p.parsePattern(); err != nil {
+				return nil, fmt.Errorf("parse binding %d pattern: %w", len(result.Values), err)
+			}
+		default:
+			return nil, fmt.Errorf("expected %s or pattern, got: %s", lexer.Identifier, tkn)
+		}
+
+		value, err := p.parseArgs()
+		if err != nil {
+			return nil, fmt.Errorf("parse binding %s: %w", name, err)
+		}
+
+		if len(value) != 1 {
+			return nil, fmt.Errorf("binding %s: expected 1 value, got %d", name, len(value))
+		}
+
+		result.Values = append(result.Values, &eval.List{
+			Values: []eval.Expression{name, value[0]},
+		})
+	}
+}
 
-	if _, err = p.readAndValidateToken(lexer.RParen); err != nil {
-		return nil, err
+// (cond (predicate) (then) (else))
+// (cond (test expr...) ... (else expr...))
+// the second form is parsed as cond([test, expr...], ..., [else, expr...]),
+// it is told from the first one by any argument, which can only be a
+// clause: the else clause, or the one with a literal or parenthesized test,
+// e.g. (true 1) or ((less x 0) 1). (x 1) is a clause in the second form and
+// a call in the first one, whatever the other arguments are
+func (p *Parser) parseCond() (eval.Expression, error) {
+	var (
+		args  []condArg
+		multi bool
+	)
+
+	for {
+		tkn, err := p.l.NextToken()
+		if err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		if tkn.Type == lexer.RParen {
+			break
+		}
+
+		arg, err := p.parseCondArg(tkn)
+		if err != nil {
+			return nil, fmt.Errorf("parse argument %d: %w", len(args), err)
+		}
+		if arg.expr == nil || len(arg.clause) > 0 && isElse(arg.clause[0]) {
+			multi = true
+		}
+		args = append(args, arg)
+	}
+
+	if !multi && len(args) > 3 {
+		return nil, fmt.Errorf("expected 2 or 3 arguments of if-then-else, got %d: "+
+			"cond clauses need the else clause or a literal or parenthesized test", len(args))
+	}
+
+	result := &eval.Call{Name: "cond"}
+	for idx, arg := range args {
+		switch {
+		case multi && arg.clause == nil:
+			return nil, fmt.Errorf("argument %d: expected clause (test expr...)", idx)
+		case multi:
+			result.Args = append(result.Args, &eval.List{Values: arg.clause})
+		default:
+			result.Args = append(result.Args, arg.expr)
+		}
 	}
 
 	return result, nil
 }
+
+// condArg is the argument of cond, read either as an expression or as a
+// clause, or as both, e.g. (x 1), until the form is known.
+type condArg struct {
+	expr   eval.Expression // nil, if the argument can only be a clause
+	clause []eval.Expression
+}
+
+func (p *Parser) parseCondArg(tkn lexer.Token) (condArg, error) {
+	if tkn.Type != lexer.LParen {
+		expr, err := p.parseExpr(tkn)
+		if err != nil {
+			return condArg{}, err
+		}
+		if expr == nil {
+			return condArg{}, fmt.Errorf("unexpected token: %s", tkn)
+		}
+		return condArg{expr: expr}, nil
+	}
+
+	cursor := p.l.Cursor()
+	tkn, err := p.l.NextToken()
+	if err != nil {
+		return condArg{}, fmt.Errorf("get next token: %w", err)
+	}
+
+	switch tkn.Type {
+	case lexer.RParen:
+		return condArg{}, errors.New("clause is empty")
+	case lexer.LParen:
+		// ((test) expr...) is always a clause, the function, which is the
+		// value of a call, is called in cond with funcall
+		p.l.UnreadToken()
+		test, err := p.ParseNext()
+		if err != nil {
+			return condArg{}, fmt.Errorf("parse test: %w", err)
+		}
+		body, err := p.parseArgs()
+		if err != nil {
+			return condArg{}, err
+		}
+		return condArg{clause: append([]eval.Expression{test}, body...)}, nil
+	}
+
+	test, err := p.parseExpr(tkn)
+	if err != nil {
+		return condArg{}, fmt.Errorf("parse test: %w", err)
+	}
+
+	if !isIdentifier(test) {
+		// a literal test, e.g. (true 1), can't be called
+		body, err := p.parseArgs()
+		if err != nil {
+			return condArg{}, err
+		}
+		return condArg{clause: append([]eval.Expression{test}, body...)}, nil
+	}
+
+	expr, err := p.findReservedKeyword(tkn)
+	switch {
+	case errors.Is(err, errNoReservedKeyword):
+	case err != nil:
+		return condArg{}, err
+	default:
+		// the special forms, e.g. (let ...), are never clauses
+		return condArg{expr: at(expr, cursor)}, nil
+	}
+
+	body, err := p.parseArgs()
+	if err != nil {
+		return condArg{}, err
+	}
+	return condArg{
+		expr:   at(&eval.Call{Name: tkn.Value, Args: body}, cursor),
+		clause: append([]eval.Expression{test}, body...),
+	}, nil
+}
+
+func isElse(expr eval.Expression) bool {
+	id, ok := expr.(*eval.Identifier)
+	return ok && id.Name == "else"
+}
+
+// (case (key) (datum expr...) ((datum1 datum2) expr...) ... (else expr...))
+// case(key, [[datum], expr...], [[datum1, datum2], expr...], ..., [else, expr...])
+func (p *Parser) parseCase() (eval.Expression, error) {
+	tkn, err := p.l.NextToken()
+	if err != nil {
+		return nil, fmt.Errorf("get next token: %w", err)
+	}
+
+	key, err := p.parseExpr(tkn)
+	if err != nil {
+		return nil, fmt.Errorf("parse key: %w", err)
+	}
+
+	result := &eval.Call{Name: "case", Args: []eval.Expression{key}}
+
+	for {
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		switch tkn.Type {
+		case lexer.RParen:
+			return result, nil
+		case lexer.LParen:
+		default:
+			return nil, fmt.Errorf("expected clause, got: %s", tkn)
+		}
+
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		var data eval.Expression
+		switch {
+		case tkn.Type == lexer.LParen:
+			values, err := p.parseArgs()
+			if err != nil {
+				return nil, fmt.Errorf("parse clause %d data: %w", len(result.Args)-1, err)
+			}
+			data = &eval.List{Values: values}
+		case tkn.Type == lexer.Identifier && tkn.Value == "else":
+			data = &eval.Identifier{Name: tkn.Value}
+		default:
+			datum, err := p.parseExpr(tkn)
+			if err != nil {
+				return nil, fmt.Errorf("parse clause %d data: %w", len(result.Args)-1, err)
+			}
+			if datum == nil {
+				return nil, fmt.Errorf("clause %d is empty", len(result.Args)-1)
+			}
+			data = &eval.List{Values: []eval.Expression{datum}}
+		}
+
+		body, err := p.parseArgs()
+		if err != nil {
+			return nil, fmt.Errorf("parse clause %d: %w", len(result.Args)-1, err)
+		}
+
+		result.Args = append(result.Args, &eval.List{Values: append([]eval.Expression{data}, body...)})
+	}
+}
+
+// (match (value) (pattern expr...) ...)
+// match(value, [pattern, expr...], ...)
+func (p *Parser) parseMatch() (eval.Expression, error) {
+	tkn, err := p.l.NextToken()
+	if err != nil {
+		return nil, fmt.Errorf("get next token: %w", err)
+	}
+
+	value, err := p.parseExpr(tkn)
+	if err != nil {
+		return nil, fmt.Errorf("parse value: %w", err)
+	}
+	if value == nil {
+		return nil, errors.New("expected value to match")
+	}
+
+	result := &eval.Call{Name: "match", Args: []eval.Expression{value}}
+
+	for {
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		switch tkn.Type {
+		case lexer.RParen:
+			return result, nil
+		case lexer.LParen:
+		default:
+			return nil, fmt.Errorf("expected clause, got: %s", tkn)
+		}
+
+		pattern, err := p.parsePattern()
+		if err != nil {
+			return nil, fmt.Errorf("parse clause %d pattern: %w", len(result.Args)-1, err)
+		}
+
+		body, err := p.parseArgs()
+		if err != nil {
+			return nil, fmt.Errorf("parse clause %d: %w", len(result.Args)-1, err)
+		}
+
+		result.Args = append(result.Args, &eval.List{Values: append([]eval.Expression{pattern}, body...)})
+	}
+}
+
+// parses a pattern of match: a literal, a variable or a constructor with
+// the patterns of its fields, e.g. (circle r), which is kept as a call
+func (p *Parser) parsePattern() (eval.Expression, error) {
+	tkn, err := p.l.NextToken()
+	if err != nil {
+		return nil, fmt.Errorf("get next token: %w", err)
+	}
+
+	switch tkn.Type {
+	case lexer.Identifier:
+		return parseIdentifier(tkn.Value), nil
+	case lexer.Number:
+		return parseNumber(tkn.Value)
+	case lexer.String:
+		return &eval.String{Value: tkn.Value}, nil
+	case lexer.SQuote:
+		return p.parseQuoted()
+	case lexer.LParen:
+	default:
+		return nil, fmt.Errorf("unexpected token: %s", tkn)
+	}
+
+	if tkn, err = p.readAndValidateToken(lexer.Identifier); err != nil {
+		return nil, fmt.Errorf("expected constructor: %w", err)
+	}
+
+	result := &eval.Call{Name: tkn.Value}
+	for {
+		if tkn, err = p.l.NextToken(); err != nil {
+			return nil, fmt.Errorf("get next token: %w", err)
+		}
+
+		if tkn.Type == lexer.RParen {
+			return result, nil
+		}
+
+		p.l.UnreadToken()
+		arg, err := p.parsePattern()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s argument %d: %w", result.Name, len(result.Args), err)
+		}
+		result.Args = append(result.Args, arg)
+	}
+}
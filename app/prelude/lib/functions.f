@@ -0,0 +1,20 @@
+// identity returns its argument.
+//
+//	(identity 5) // 5
+(func identity (x) x)
+
+// compose returns the function, which applies g and then f to its
+// argument.
+//
+//	((compose abs dec) 0) // 1
+(func compose (f g) (lambda (x) (f (g x))))
+
+// partial binds the first argument of the function of two arguments.
+//
+//	((partial plus 1) 41) // 42
+(func partial (f x) (lambda (y) (f x y)))
+
+// flip swaps the arguments of the function of two arguments.
+//
+//	((flip minus) 1 10) // 9
+(func flip (f) (lambda (a b) (f b a)))
@@ -0,0 +1,65 @@
+// listLength returns the number of elements of the list.
+//
+//	(listLength '(1 2 3)) // 3
+(func listLength (list) (length list))
+
+// sum returns the sum of the numbers of the list.
+//
+//	(sum '(1 2 3)) // 6
+//	(sum null)     // 0
+(func sum (list) (foldl plus 0 list))
+
+// product returns the product of the numbers of the list.
+//
+//	(product '(1 2 3 4)) // 24
+(func product (list) (foldl times 1 list))
+
+// any tells whether the predicate is true for any element of the list.
+//
+//	(any isEven '(1 3 4)) // true
+(func any (pred list)
+    (cond ((empty list) false)
+          ((pred (head list)) true)
+          (else (any pred (tail list)))))
+
+// all tells whether the predicate is true for every element of the list.
+//
+//	(all isEven '(2 3 4)) // false
+(func all (pred list)
+    (cond ((empty list) true)
+          ((pred (head list)) (all pred (tail list)))
+          (else false)))
+
+// count returns the number of elements, for which the predicate is true.
+//
+//	(count isOdd '(1 2 3)) // 2
+(func count (pred list) (length (filter pred list)))
+
+// remove returns the list without the elements equal to the value.
+//
+//	(remove 2 '(1 2 3 2)) // '(1 3)
+(func remove (x list) (filter (lambda (y) (not (equal x y))) list))
+
+// repeat returns the list of n values.
+//
+//	(repeat 0 3) // '(0 0 0)
+(func repeat (x n) (map (lambda (i) x) (range n)))
+
+// unique returns the list without the repeated elements, keeping the first
+// of them.
+//
+//	(unique '(1 2 1 3 2)) // '(1 2 3)
+(func unique (list)
+    (reverse (foldl (lambda (acc x) (cond (member x acc) acc (cons x acc))) null list)))
+
+// indexOf returns the index of the first element equal to the value, or -1
+// if there is none.
+//
+//	(indexOf 3 '(1 2 3)) // 2
+//	(indexOf 4 '(1 2 3)) // (minus 0 1)
+(func indexOf (x list)
+    (letrec ((find (lambda (l i)
+                (cond ((empty l) (minus 0 1))
+                      ((equal (head l) x) i)
+                      (else (find (tail l) (inc i)))))))
+        (find list 0)))
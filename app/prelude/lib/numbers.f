@@ -0,0 +1,37 @@
+// inc returns the number plus one.
+//
+//	(inc 41) // 42
+(func inc (n) (plus n 1))
+
+// dec returns the number minus one.
+//
+//	(dec 43) // 42
+(func dec (n) (minus n 1))
+
+// square returns the number times itself.
+//
+//	(square 5) // 25
+(func square (n) (times n n))
+
+// isEven tells whether the integer is even.
+//
+//	(isEven 4) // true
+(func isEven (n) (equal (modulo n 2) 0))
+
+// isOdd tells whether the integer is odd.
+//
+//	(isOdd 4) // false
+(func isOdd (n) (not (isEven n)))
+
+// factorial returns the product of the integers from 1 to n.
+//
+//	(factorial 5)  // 120
+//	(factorial 0)  // 1
+(func factorial (n) (foldl times 1 (range 1 (inc n))))
+
+// fibonacci returns the n-th Fibonacci number, counting from 0.
+//
+//	(fibonacci 10) // 55
+(func fibonacci (n)
+    (letrec ((fib (lambda (k a b) (cond (equal k 0) a (fib (dec k) b (plus a b))))))
+        (fib n 0 1)))
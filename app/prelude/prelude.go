@@ -0,0 +1,76 @@
+// Package prelude contains the standard functions of F, written in F and
+// embedded into the binary.
+package prelude
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/lexer"
+	"github.com/cappuccinotm/flangc/app/parser"
+)
+
+// the sources are kept aside from the package, as go takes .f files for
+// Fortran
+//
+//go:embed lib/*.f
+var files embed.FS
+
+// Load evaluates the prelude in the scope, so that its functions are
+// defined there.
+func Load(scope *eval.Scope) error {
+	entries, err := files.ReadDir("lib")
+	if err != nil {
+		return fmt.Errorf("read prelude: %w", err)
+	}
+
+	for _, entry := range entries {
+		if err = loadFile(scope, entry.Name()); err != nil {
+			return fmt.Errorf("load %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// NewScope returns the root scope of a program with the prelude loaded into
+// its parent, so that the program may declare its own variables with the
+// names the prelude uses, shadowing them only for itself. The definitions
+// of the prelude are constants, so the program can't change them with set!
+// and the functions of the prelude always see their own definitions.
+func NewScope(printNulls bool) (*eval.Scope, error) {
+	lib := eval.NewScope("", nil, printNulls)
+	if err := Load(lib); err != nil {
+		return nil, err
+	}
+	for name, val := range lib.Vars {
+		lib.SetConst(name, val)
+	}
+	return eval.NewScope("", lib, printNulls), nil
+}
+
+func loadFile(scope *eval.Scope, name string) error {
+	f, err := files.Open("lib/" + name)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	p := parser.NewParser(lexer.NewLexer(f))
+	for {
+		expr, err := p.ParseNext()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse: %w", err)
+		}
+
+		if _, err = scope.Eval(expr); err != nil {
+			return fmt.Errorf("evaluate %s: %w", expr, err)
+		}
+	}
+}
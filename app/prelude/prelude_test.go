@@ -0,0 +1,130 @@
+package prelude_test
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cappuccinotm/flangc/app/eval"
+	"github.com/cappuccinotm/flangc/app/lexer"
+	"github.com/cappuccinotm/flangc/app/parser"
+	"github.com/cappuccinotm/flangc/app/prelude"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// example is a line of the doc comment, which shows an expression and its
+// value, e.g. "//	(inc 41) // 42"
+var example = regexp.MustCompile(`^//\t(.+?)\s*// (.+)$`)
+
+func TestLoad(t *testing.T) {
+	scope := eval.NewScope("", nil, false)
+	require.NoError(t, prelude.Load(scope))
+
+	fn, err := scope.GetVar("listLength", false)
+	require.NoError(t, err)
+	assert.Equal(t, "function", fn.Type())
+}
+
+func TestNewScope(t *testing.T) {
+	scope, err := prelude.NewScope(false)
+	require.NoError(t, err)
+	scope.SetStrict(true)
+
+	for _, src := range []string{
+		"(define count 0)", "(const sum 10)", "(func inc (x) (plus x 2))",
+		"(setq count (plus count 1))",
+	} {
+		_, err = scope.Eval(parse(t, src))
+		require.NoError(t, err, src)
+	}
+
+	tbl := []struct {
+		src  string
+		want eval.Expression
+	}{
+		{src: "(plus count sum)", want: &eval.Integer{Value: 11}},
+		{src: "(inc 1)", want: &eval.Integer{Value: 3}},
+		// the prelude keeps its own definitions
+		{src: "(unique '(1 1 2))", want: &eval.List{Values: []eval.Expression{
+			&eval.Integer{Value: 1}, &eval.Integer{Value: 2},
+		}}},
+		{src: "(factorial 3)", want: &eval.Integer{Value: 6}},
+	}
+
+	for _, tt := range tbl {
+		t.Run(tt.src, func(t *testing.T) {
+			res, err := scope.Eval(parse(t, tt.src))
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(res), "expected %s, got %s", tt.want, res)
+		})
+	}
+
+	_, err = scope.Eval(parse(t, "(set! dec (lambda (n) n))"))
+	assert.ErrorIs(t, err, eval.ErrConstant{Name: "dec"})
+	res, err := scope.Eval(parse(t, "(fibonacci 5)"))
+	require.NoError(t, err)
+	assert.True(t, (&eval.Integer{Value: 5}).Equal(res), "got %s", res)
+}
+
+func TestLoad_Limits(t *testing.T) {
+	scope := eval.NewScope("", nil, false)
+	require.NoError(t, prelude.Load(scope))
+	scope.SetLimits(eval.Limits{MaxSteps: 20, MaxValues: 5})
+
+	for _, src := range []string{"(setq x 1)", "(setq y (plus x 1))", "(inc y)"} {
+		_, err := scope.Eval(parse(t, src))
+		require.NoError(t, err, "the prelude must not take from the budget of %s", src)
+	}
+
+	_, err := scope.Eval(parse(t, "(while true (setq x 1))"))
+	var limitErr eval.ErrLimitExceeded
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, "steps", limitErr.Limit)
+}
+
+func TestExamples(t *testing.T) {
+	scope := eval.NewScope("", nil, false)
+	require.NoError(t, prelude.Load(scope))
+
+	paths, err := filepath.Glob("lib/*.f")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+
+		sc := bufio.NewScanner(f)
+		for line := 1; sc.Scan(); line++ {
+			m := example.FindStringSubmatch(sc.Text())
+			if m == nil {
+				continue
+			}
+
+			src, want := m[1], m[2]
+			t.Run(filepath.Base(path)+"/"+src, func(t *testing.T) {
+				res, err := scope.Eval(parse(t, src))
+				require.NoError(t, err, "line %d", line)
+
+				// a literal can't be parsed as a top-level expression
+				expected, err := scope.Eval(parse(t, "(identity "+want+")"))
+				require.NoError(t, err, "line %d", line)
+
+				assert.True(t, expected.Equal(res), "line %d: expected %s, got %s", line, expected, res)
+			})
+		}
+		require.NoError(t, sc.Err())
+		require.NoError(t, f.Close())
+	}
+}
+
+func parse(t *testing.T, src string) eval.Expression {
+	t.Helper()
+	expr, err := parser.NewParser(lexer.NewLexer(strings.NewReader(src))).ParseNext()
+	require.NoError(t, err)
+	return expr
+}